@@ -0,0 +1,112 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MessageAccumulator builds the final ChatMessage from the events of a streamed chat.
+//
+// Text deltas are joined into their text content blocks, partial JSON deltas are
+// assembled into the input of their tool use content blocks, and the usage is
+// combined from the message_start and message_delta events.
+type MessageAccumulator struct {
+	message ChatMessage
+	inputs  map[int]*strings.Builder
+}
+
+// Add adds an event to the accumulated message.
+func (a *MessageAccumulator) Add(event Event) error {
+	switch e := event.(type) {
+	case MessageStartEvent:
+		a.message = e.Message
+		a.message.Content = nil
+		a.inputs = nil
+	case ContentBlockStartEvent:
+		if e.Index < 0 {
+			return fmt.Errorf("invalid content block index %d", e.Index)
+		}
+		for len(a.message.Content) <= e.Index {
+			a.message.Content = append(a.message.Content, nil)
+		}
+		a.message.Content[e.Index] = e.ContentBlock
+	case ContentBlockDeltaEvent:
+		if e.Index < 0 || e.Index >= len(a.message.Content) {
+			return fmt.Errorf("content block delta for unknown index %d", e.Index)
+		}
+		if a.message.Content[e.Index] == nil {
+			// The content block type is not supported, so its deltas are skipped.
+			return nil
+		}
+		switch delta := e.Delta.(type) {
+		case TextDelta:
+			block, ok := a.message.Content[e.Index].(TextContent)
+			if !ok {
+				return fmt.Errorf("text delta for %s content block at index %d", a.message.Content[e.Index].Type(), e.Index)
+			}
+			block.Text += delta.Text
+			a.message.Content[e.Index] = block
+		case InputJSONDelta:
+			if _, ok := a.message.Content[e.Index].(ToolUseContent); !ok {
+				return fmt.Errorf("input json delta for %s content block at index %d", a.message.Content[e.Index].Type(), e.Index)
+			}
+			if a.inputs == nil {
+				a.inputs = make(map[int]*strings.Builder)
+			}
+			input, ok := a.inputs[e.Index]
+			if !ok {
+				input = &strings.Builder{}
+				a.inputs[e.Index] = input
+			}
+			input.WriteString(delta.PartialJSON)
+		}
+	case ContentBlockStopEvent:
+		return a.finishToolInput(e.Index)
+	case MessageDeltaEvent:
+		a.message.StopReason = e.Delta.StopReason
+		a.message.StopSequence = e.Delta.StopSequence
+		a.message.Usage.OutputTokens = e.Usage.OutputTokens
+	}
+	return nil
+}
+
+// Message returns the accumulated message.
+func (a *MessageAccumulator) Message() (*ChatMessage, error) {
+	for index := range a.inputs {
+		if err := a.finishToolInput(index); err != nil {
+			return nil, err
+		}
+	}
+
+	message := a.message
+	message.Content = make([]Content, 0, len(a.message.Content))
+	for _, content := range a.message.Content {
+		if content != nil {
+			message.Content = append(message.Content, content)
+		}
+	}
+	return &message, nil
+}
+
+// finishToolInput sets the assembled input of the tool use content block at index.
+func (a *MessageAccumulator) finishToolInput(index int) error {
+	input, ok := a.inputs[index]
+	if !ok {
+		return nil
+	}
+	delete(a.inputs, index)
+
+	block, ok := a.message.Content[index].(ToolUseContent)
+	if !ok {
+		return nil
+	}
+	if input.Len() > 0 {
+		if !json.Valid([]byte(input.String())) {
+			return fmt.Errorf("invalid tool input json for content block at index %d", index)
+		}
+		block.Input = json.RawMessage(input.String())
+	}
+	a.message.Content[index] = block
+	return nil
+}
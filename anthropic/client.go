@@ -1,14 +1,14 @@
 package anthropic
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
-	"io"
-	"log"
 	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joeychilson/ai/retry"
 )
 
 const (
@@ -17,18 +17,33 @@ const (
 
 // Client is a client for the Anthropic API.
 type Client struct {
-	baseURL    string
-	token      string
-	httpClient *http.Client
-}
-
-// New creates a new Client using the given token.
-func New(token string) *Client {
-	return &Client{
-		baseURL:    defaultBaseURL,
-		token:      token,
-		httpClient: http.DefaultClient,
+	baseURL     string
+	token       string
+	httpClient  *http.Client
+	headers     http.Header
+	userAgent   string
+	timeout     time.Duration
+	retryPolicy retry.Policy
+}
+
+// New creates a new Client using the given token and options.
+func New(token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:     defaultBaseURL,
+		token:       token,
+		httpClient:  http.DefaultClient,
+		headers:     make(http.Header),
+		retryPolicy: retry.DefaultPolicy(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.timeout > 0 {
+		httpClient := *c.httpClient
+		httpClient.Timeout = c.timeout
+		c.httpClient = &httpClient
 	}
+	return c
 }
 
 // LanguageModel represents the Anthropic language model.
@@ -107,6 +122,151 @@ func (c ImageContent) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// ToolUseContent represents a request from the assistant to use a tool.
+type ToolUseContent struct {
+	ID    string          `json:"id"`
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input"`
+}
+
+// Type returns the type of the tool use content.
+func (c ToolUseContent) Type() string {
+	return "tool_use"
+}
+
+// MarshalJSON marshals the tool use content to JSON.
+func (c ToolUseContent) MarshalJSON() ([]byte, error) {
+	input := c.Input
+	if len(input) == 0 {
+		input = json.RawMessage("{}")
+	}
+	return json.Marshal(struct {
+		Type  string          `json:"type"`
+		ID    string          `json:"id"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
+	}{
+		Type:  c.Type(),
+		ID:    c.ID,
+		Name:  c.Name,
+		Input: input,
+	})
+}
+
+// ToolResultContent represents the result of a tool use in the chat.
+type ToolResultContent struct {
+	ToolUseID string    `json:"tool_use_id"`
+	Content   []Content `json:"content,omitempty"`
+	IsError   bool      `json:"is_error,omitempty"`
+}
+
+// Type returns the type of the tool result content.
+func (c ToolResultContent) Type() string {
+	return "tool_result"
+}
+
+// MarshalJSON marshals the tool result content to JSON.
+func (c ToolResultContent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type      string    `json:"type"`
+		ToolUseID string    `json:"tool_use_id"`
+		Content   []Content `json:"content,omitempty"`
+		IsError   bool      `json:"is_error,omitempty"`
+	}{
+		Type:      c.Type(),
+		ToolUseID: c.ToolUseID,
+		Content:   c.Content,
+		IsError:   c.IsError,
+	})
+}
+
+// UnmarshalJSON unmarshals the tool result content from JSON.
+// The content may either be a string or a list of content blocks.
+func (c *ToolResultContent) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		ToolUseID string          `json:"tool_use_id"`
+		Content   json.RawMessage `json:"content"`
+		IsError   bool            `json:"is_error"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	c.ToolUseID = aux.ToolUseID
+	c.IsError = aux.IsError
+	c.Content = nil
+
+	if len(aux.Content) == 0 || string(aux.Content) == "null" {
+		return nil
+	}
+	if aux.Content[0] == '"' {
+		var text string
+		if err := json.Unmarshal(aux.Content, &text); err != nil {
+			return err
+		}
+		c.Content = []Content{TextContent{Text: text}}
+		return nil
+	}
+
+	var blocks []json.RawMessage
+	if err := json.Unmarshal(aux.Content, &blocks); err != nil {
+		return err
+	}
+	content, err := unmarshalContents(blocks)
+	if err != nil {
+		return err
+	}
+	c.Content = content
+	return nil
+}
+
+// unmarshalContent decodes a content block into its concrete type.
+// It returns nil for content types that are not supported.
+func unmarshalContent(data []byte) (Content, error) {
+	var block struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &block); err != nil {
+		return nil, err
+	}
+
+	switch block.Type {
+	case "text":
+		var content TextContent
+		err := json.Unmarshal(data, &content)
+		return content, err
+	case "image":
+		var content ImageContent
+		err := json.Unmarshal(data, &content)
+		return content, err
+	case "tool_use":
+		var content ToolUseContent
+		err := json.Unmarshal(data, &content)
+		return content, err
+	case "tool_result":
+		var content ToolResultContent
+		err := json.Unmarshal(data, &content)
+		return content, err
+	default:
+		return nil, nil
+	}
+}
+
+// unmarshalContents decodes a list of content blocks, skipping unsupported content types.
+func unmarshalContents(blocks []json.RawMessage) ([]Content, error) {
+	contents := make([]Content, 0, len(blocks))
+	for _, block := range blocks {
+		content, err := unmarshalContent(block)
+		if err != nil {
+			return nil, err
+		}
+		if content != nil {
+			contents = append(contents, content)
+		}
+	}
+	return contents, nil
+}
+
 // Message represents a message in the chat.
 type Message interface {
 	Role() Role
@@ -159,6 +319,31 @@ type Metadata struct {
 	UserID string `json:"user_id"`
 }
 
+// Tool describes a tool the model may use.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema any    `json:"input_schema"`
+}
+
+// ToolChoiceType represents how the model should use the provided tools.
+type ToolChoiceType string
+
+const (
+	// ToolChoiceAuto lets the model decide whether to use a tool.
+	ToolChoiceAuto ToolChoiceType = "auto"
+	// ToolChoiceAny forces the model to use one of the tools.
+	ToolChoiceAny ToolChoiceType = "any"
+	// ToolChoiceTool forces the model to use the named tool.
+	ToolChoiceTool ToolChoiceType = "tool"
+)
+
+// ToolChoice describes how the model should use the provided tools.
+type ToolChoice struct {
+	Type ToolChoiceType `json:"type"`
+	Name string         `json:"name,omitempty"`
+}
+
 // ChatRequest describes a request to the messages API.
 type ChatRequest struct {
 	Model         LanguageModel `json:"model"`
@@ -171,6 +356,8 @@ type ChatRequest struct {
 	Temperature   float32       `json:"temperature,omitempty"`
 	TopP          float32       `json:"top_p,omitempty"`
 	TopK          int           `json:"top_k,omitempty"`
+	Tools         []Tool        `json:"tools,omitempty"`
+	ToolChoice    *ToolChoice   `json:"tool_choice,omitempty"`
 }
 
 // Usage describes the usage billing and limits usage.
@@ -184,13 +371,61 @@ type ChatMessage struct {
 	ID           string        `json:"id"`
 	Type         string        `json:"type"`
 	Role         Role          `json:"role"`
-	Content      []TextContent `json:"content"`
+	Content      []Content     `json:"content"`
 	Model        LanguageModel `json:"model"`
 	StopReason   string        `json:"stop_reason"`
 	StopSequence string        `json:"stop_sequence"`
 	Usage        Usage         `json:"usage"`
 }
 
+// UnmarshalJSON unmarshals the chat message from JSON, decoding each content block into its concrete type.
+func (m *ChatMessage) UnmarshalJSON(data []byte) error {
+	type alias ChatMessage
+	aux := struct {
+		*alias
+		Content []json.RawMessage `json:"content"`
+	}{
+		alias: (*alias)(m),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	content, err := unmarshalContents(aux.Content)
+	if err != nil {
+		return err
+	}
+	m.Content = content
+	return nil
+}
+
+// Text returns the concatenated text of the text content blocks.
+func (m *ChatMessage) Text() string {
+	var text strings.Builder
+	for _, content := range m.Content {
+		if c, ok := content.(TextContent); ok {
+			text.WriteString(c.Text)
+		}
+	}
+	return text.String()
+}
+
+// ToolUses returns the tool use content blocks of the message.
+func (m *ChatMessage) ToolUses() []ToolUseContent {
+	var toolUses []ToolUseContent
+	for _, content := range m.Content {
+		if c, ok := content.(ToolUseContent); ok {
+			toolUses = append(toolUses, c)
+		}
+	}
+	return toolUses
+}
+
+// AssistantMessage returns the message as an assistant message, so it can be sent back in a follow-up request.
+func (m *ChatMessage) AssistantMessage() AssistantMessage {
+	return AssistantMessage{Content: m.Content}
+}
+
 // ErrorResponse describes an error response.
 type ErrorResponse struct {
 	Type  string `json:"type"`
@@ -249,12 +484,9 @@ func (e MessageStartEvent) EventType() string {
 
 // ContentBlockStartEvent represents the content_block_start event.
 type ContentBlockStartEvent struct {
-	Type         string `json:"type"`
-	Index        int    `json:"index"`
-	ContentBlock struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	} `json:"content_block"`
+	Type         string  `json:"type"`
+	Index        int     `json:"index"`
+	ContentBlock Content `json:"content_block"`
 }
 
 // EventType returns the type of the content_block_start event.
@@ -262,14 +494,57 @@ func (e ContentBlockStartEvent) EventType() string {
 	return "content_block_start"
 }
 
+// UnmarshalJSON unmarshals the content_block_start event from JSON, decoding the content block into its concrete type.
+func (e *ContentBlockStartEvent) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type         string          `json:"type"`
+		Index        int             `json:"index"`
+		ContentBlock json.RawMessage `json:"content_block"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	content, err := unmarshalContent(aux.ContentBlock)
+	if err != nil {
+		return err
+	}
+	e.Type = aux.Type
+	e.Index = aux.Index
+	e.ContentBlock = content
+	return nil
+}
+
+// Delta represents an incremental update to a content block.
+type Delta interface {
+	DeltaType() string
+}
+
+// TextDelta represents a text delta of a text content block.
+type TextDelta struct {
+	Text string `json:"text"`
+}
+
+// DeltaType returns the type of the text delta.
+func (d TextDelta) DeltaType() string {
+	return "text_delta"
+}
+
+// InputJSONDelta represents a partial JSON delta of a tool use content block input.
+type InputJSONDelta struct {
+	PartialJSON string `json:"partial_json"`
+}
+
+// DeltaType returns the type of the input JSON delta.
+func (d InputJSONDelta) DeltaType() string {
+	return "input_json_delta"
+}
+
 // ContentBlockDeltaEvent represents the content_block_delta event.
 type ContentBlockDeltaEvent struct {
 	Type  string `json:"type"`
 	Index int    `json:"index"`
-	Delta struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	} `json:"delta"`
+	Delta Delta  `json:"delta"`
 }
 
 // EventType returns the type of the content_block_delta event.
@@ -277,6 +552,46 @@ func (e ContentBlockDeltaEvent) EventType() string {
 	return "content_block_delta"
 }
 
+// UnmarshalJSON unmarshals the content_block_delta event from JSON, decoding the delta into its concrete type.
+// Delta types that are not supported are left nil.
+func (e *ContentBlockDeltaEvent) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Type  string          `json:"type"`
+		Index int             `json:"index"`
+		Delta json.RawMessage `json:"delta"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var delta struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(aux.Delta, &delta); err != nil {
+		return err
+	}
+
+	e.Type = aux.Type
+	e.Index = aux.Index
+	e.Delta = nil
+
+	switch delta.Type {
+	case "text_delta":
+		var textDelta TextDelta
+		if err := json.Unmarshal(aux.Delta, &textDelta); err != nil {
+			return err
+		}
+		e.Delta = textDelta
+	case "input_json_delta":
+		var inputJSONDelta InputJSONDelta
+		if err := json.Unmarshal(aux.Delta, &inputJSONDelta); err != nil {
+			return err
+		}
+		e.Delta = inputJSONDelta
+	}
+	return nil
+}
+
 // ContentBlockStopEvent represents the content_block_stop event.
 type ContentBlockStopEvent struct {
 	Type  string `json:"type"`
@@ -320,97 +635,16 @@ type StreamCallback func(ctx context.Context, event Event)
 
 // ChatStream streams the chat with the given messages and calls the callback for each response.
 func (c *Client) ChatStream(ctx context.Context, req *ChatRequest, callback StreamCallback) error {
-	req.Stream = true
-
-	resp, err := c.request(ctx, req)
+	stream, err := c.NewChatStream(ctx, req)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return c.decodeError(resp)
-	}
-
-	reader := bufio.NewReader(resp.Body)
-	for {
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return err
-		}
-
-		line = bytes.TrimSpace(line)
-		if len(line) == 0 || bytes.HasPrefix(line, []byte("event:")) {
-			continue
-		}
-
-		eventData := bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+	defer stream.Close()
 
-		var event struct {
-			Type string `json:"type"`
-		}
-		if err := json.Unmarshal(eventData, &event); err != nil {
-			return err
-		}
-
-		switch event.Type {
-		case "ping":
-			var pingEvent PingEvent
-			if err := json.Unmarshal(eventData, &pingEvent); err != nil {
-				return err
-			}
-			callback(ctx, pingEvent)
-		case "message_start":
-			var messageStartEvent MessageStartEvent
-			if err := json.Unmarshal(eventData, &messageStartEvent); err != nil {
-				return err
-			}
-			callback(ctx, messageStartEvent)
-		case "content_block_start":
-			var contentBlockStartEvent ContentBlockStartEvent
-			if err := json.Unmarshal(eventData, &contentBlockStartEvent); err != nil {
-				return err
-			}
-			callback(ctx, contentBlockStartEvent)
-		case "content_block_delta":
-			var contentBlockDeltaEvent ContentBlockDeltaEvent
-			if err := json.Unmarshal(eventData, &contentBlockDeltaEvent); err != nil {
-				return err
-			}
-			callback(ctx, contentBlockDeltaEvent)
-		case "content_block_stop":
-			var contentBlockStopEvent ContentBlockStopEvent
-			if err := json.Unmarshal(eventData, &contentBlockStopEvent); err != nil {
-				return err
-			}
-			callback(ctx, contentBlockStopEvent)
-		case "message_delta":
-			var messageDeltaEvent MessageDeltaEvent
-			if err := json.Unmarshal(eventData, &messageDeltaEvent); err != nil {
-				return err
-			}
-			callback(ctx, messageDeltaEvent)
-		case "message_stop":
-			var messageStopEvent MessageStopEvent
-			if err := json.Unmarshal(eventData, &messageStopEvent); err != nil {
-				return err
-			}
-			callback(ctx, messageStopEvent)
-			return nil
-		case "error":
-			var errResp ErrorResponse
-			if err := json.Unmarshal(eventData, &errResp); err != nil {
-				return err
-			}
-			return fmt.Errorf("%s: %s", errResp.Error.Type, errResp.Error.Message)
-		default:
-			log.Printf("unknown event type: %s", event.Type)
-		}
+	for stream.Next() {
+		callback(ctx, stream.Current())
 	}
-	return nil
+	return stream.Err()
 }
 
 func (c *Client) request(ctx context.Context, req any) (*http.Response, error) {
@@ -427,16 +661,18 @@ func (c *Client) request(ctx context.Context, req any) (*http.Response, error) {
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", c.token)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
 
-	return c.httpClient.Do(httpReq)
+	return c.do(httpReq)
 }
 
-func (c *Client) decodeError(resp *http.Response) error {
-	var errResp ErrorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-		return fmt.Errorf("error decoding error response: %w", err)
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("x-api-key", c.token)
+	req.Header.Set("anthropic-version", "2023-06-01")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
 	}
-	return fmt.Errorf("%s: %s", errResp.Error.Type, errResp.Error.Message)
+	return c.retryPolicy.Do(c.httpClient, req)
 }
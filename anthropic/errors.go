@@ -0,0 +1,89 @@
+package anthropic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// APIError is an error returned by the Anthropic API.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+	Param      string
+	RequestID  string
+	Body       []byte
+}
+
+// Error returns the error message.
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("anthropic: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), truncate(string(e.Body), 200))
+	}
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("anthropic: %s: %s", e.Type, e.Message)
+	}
+	return fmt.Sprintf("anthropic: %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// IsRateLimited reports whether err is an API error caused by rate limiting.
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusTooManyRequests || apiErr.Type == "rate_limit_error")
+}
+
+// IsOverloaded reports whether err is an API error caused by the API being overloaded.
+func IsOverloaded(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == 529 || apiErr.Type == "overloaded_error")
+}
+
+// IsAuth reports whether err is an API error caused by invalid credentials or missing permissions.
+func IsAuth(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// IsNotFound reports whether err is an API error caused by a missing resource.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsContextLength reports whether err is an API error caused by a prompt exceeding the context window.
+func IsContextLength(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Type == "invalid_request_error" && strings.Contains(apiErr.Message, "prompt is too long")
+}
+
+func (c *Client) decodeError(resp *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("error reading error response: %w", err)
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("request-id"),
+		Body:       body,
+	}
+
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		apiErr.Type = errResp.Error.Type
+		apiErr.Message = errResp.Error.Message
+	}
+	return apiErr
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,203 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/joeychilson/ai/internal/sse"
+)
+
+// Stream is a stream of events from a streamed chat.
+//
+// Events are read one at a time in the style of bufio.Scanner:
+//
+//	stream, err := client.NewChatStream(ctx, req)
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//
+//	for stream.Next() {
+//		event := stream.Current()
+//		// ...
+//	}
+//	if err := stream.Err(); err != nil {
+//		return err
+//	}
+type Stream struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	body      io.ReadCloser
+	reader    *sse.Reader
+	requestID string
+	current   Event
+	err       error
+	done      bool
+}
+
+// NewChatStream starts a streamed chat with the given messages and returns the stream of events.
+// The stream must be closed when it is no longer used.
+func (c *Client) NewChatStream(ctx context.Context, req *ChatRequest) (*Stream, error) {
+	req.Stream = true
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	resp, err := c.request(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer cancel()
+		defer resp.Body.Close()
+		return nil, c.decodeError(resp)
+	}
+
+	return &Stream{
+		ctx:       ctx,
+		cancel:    cancel,
+		body:      resp.Body,
+		reader:    sse.NewReader(resp.Body),
+		requestID: resp.Header.Get("request-id"),
+	}, nil
+}
+
+// Next advances the stream to the next event, which is then available through Current.
+// It returns false when the stream ends or an error occurs, in which case Err returns the error.
+func (s *Stream) Next() bool {
+	if s.done {
+		return false
+	}
+
+	for {
+		sseEvent, err := s.reader.Next()
+		if err != nil {
+			s.done = true
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+
+		event, err := s.decodeEvent(sseEvent.Data)
+		if err != nil {
+			s.done = true
+			s.err = err
+			return false
+		}
+		if event == nil {
+			continue
+		}
+
+		if _, ok := event.(MessageStopEvent); ok {
+			s.done = true
+		}
+		s.current = event
+		return true
+	}
+}
+
+// Current returns the most recent event read by Next.
+func (s *Stream) Current() Event {
+	return s.current
+}
+
+// Err returns the first error that occurred while reading the stream.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// Close stops the stream and closes the underlying response body.
+func (s *Stream) Close() error {
+	s.cancel()
+	return s.body.Close()
+}
+
+// Events returns a channel that receives the events of the stream.
+// The channel is closed when the stream ends or is closed, after which Err reports any error.
+func (s *Stream) Events() <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for s.Next() {
+			select {
+			case events <- s.Current():
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+	return events
+}
+
+// ForEach calls fn for each event of the stream and closes the stream when done.
+// If fn returns an error, the stream is stopped and the error is returned.
+func (s *Stream) ForEach(fn func(event Event) error) error {
+	defer s.Close()
+
+	for s.Next() {
+		if err := fn(s.Current()); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
+
+// decodeEvent decodes the data of an event. It returns nil for unknown event types.
+func (s *Stream) decodeEvent(data []byte) (Event, error) {
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+
+	switch event.Type {
+	case "ping":
+		var pingEvent PingEvent
+		err := json.Unmarshal(data, &pingEvent)
+		return pingEvent, err
+	case "message_start":
+		var messageStartEvent MessageStartEvent
+		err := json.Unmarshal(data, &messageStartEvent)
+		return messageStartEvent, err
+	case "content_block_start":
+		var contentBlockStartEvent ContentBlockStartEvent
+		err := json.Unmarshal(data, &contentBlockStartEvent)
+		return contentBlockStartEvent, err
+	case "content_block_delta":
+		var contentBlockDeltaEvent ContentBlockDeltaEvent
+		err := json.Unmarshal(data, &contentBlockDeltaEvent)
+		return contentBlockDeltaEvent, err
+	case "content_block_stop":
+		var contentBlockStopEvent ContentBlockStopEvent
+		err := json.Unmarshal(data, &contentBlockStopEvent)
+		return contentBlockStopEvent, err
+	case "message_delta":
+		var messageDeltaEvent MessageDeltaEvent
+		err := json.Unmarshal(data, &messageDeltaEvent)
+		return messageDeltaEvent, err
+	case "message_stop":
+		var messageStopEvent MessageStopEvent
+		err := json.Unmarshal(data, &messageStopEvent)
+		return messageStopEvent, err
+	case "error":
+		var errResp ErrorResponse
+		if err := json.Unmarshal(data, &errResp); err != nil {
+			return nil, err
+		}
+		return nil, &APIError{
+			Type:      errResp.Error.Type,
+			Message:   errResp.Error.Message,
+			RequestID: s.requestID,
+			Body:      data,
+		}
+	default:
+		log.Printf("unknown event type: %s", event.Type)
+		return nil, nil
+	}
+}
@@ -0,0 +1,33 @@
+// Package embedding defines a provider-neutral embedding interface, so ingestion code does not need
+// to know which embedding vendor produced the vectors.
+package embedding
+
+import (
+	"context"
+	"fmt"
+)
+
+// Embedder is a model that embeds text into vectors.
+type Embedder interface {
+	// EmbedDocuments embeds the given documents, returning one vector per document in order.
+	EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error)
+	// EmbedQuery embeds the given search query.
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+	// Dimension returns the dimension of the vectors, or 0 if it is unknown.
+	Dimension() int
+}
+
+// ordered places the vectors at their index, checking that every input has exactly one vector.
+func ordered(n int, indexes []int, vectors [][]float32) ([][]float32, error) {
+	if len(vectors) != n {
+		return nil, fmt.Errorf("embedding: expected %d embeddings, got %d", n, len(vectors))
+	}
+	out := make([][]float32, n)
+	for i, index := range indexes {
+		if index < 0 || index >= n || out[index] != nil {
+			return nil, fmt.Errorf("embedding: invalid embedding index %d", index)
+		}
+		out[index] = vectors[i]
+	}
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package embedding
+
+import (
+	"context"
+
+	"github.com/joeychilson/ai/openai"
+)
+
+var openaiDimensions = map[openai.EmbeddingModel]int{
+	openai.ModelTextEmbeddingADA_002: 1536,
+	openai.ModelTextEmbedding3_Small: 1536,
+	openai.ModelTextEmbedding3_Large: 3072,
+}
+
+// OpenAI is an Embedder backed by the OpenAI embeddings API.
+type OpenAI struct {
+	client     *openai.Client
+	model      openai.EmbeddingModel
+	dimensions int
+}
+
+// NewOpenAI creates a new OpenAI embedder using the given client and model.
+// If dimensions is greater than 0, the embeddings are shortened to it, which is
+// only supported by the text-embedding-3 models.
+func NewOpenAI(client *openai.Client, model openai.EmbeddingModel, dimensions int) *OpenAI {
+	return &OpenAI{
+		client:     client,
+		model:      model,
+		dimensions: dimensions,
+	}
+}
+
+// EmbedDocuments embeds the given documents.
+func (e *OpenAI) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	resp, err := e.client.EmbedAll(ctx, &openai.EmbedRequest{
+		Input:      texts,
+		Model:      e.model,
+		Dimensions: e.dimensions,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int, len(resp.Data))
+	vectors := make([][]float32, len(resp.Data))
+	for i, data := range resp.Data {
+		indexes[i] = data.Index
+		vectors[i] = data.Embedding
+	}
+	return ordered(len(texts), indexes, vectors)
+}
+
+// EmbedQuery embeds the given search query.
+func (e *OpenAI) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.EmbedDocuments(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// Dimension returns the dimension of the vectors.
+func (e *OpenAI) Dimension() int {
+	if e.dimensions > 0 {
+		return e.dimensions
+	}
+	return openaiDimensions[e.model]
+}
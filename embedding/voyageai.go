@@ -0,0 +1,71 @@
+package embedding
+
+import (
+	"context"
+
+	"github.com/joeychilson/ai/voyageai"
+)
+
+var voyageDimensions = map[voyageai.EmbeddingModel]int{
+	voyageai.ModelVoyage2:      1024,
+	voyageai.ModelVoyageLarge2: 1536,
+	voyageai.ModelVoyageLaw2:   1024,
+	voyageai.ModelVoyageCode2:  1536,
+}
+
+// VoyageAI is an Embedder backed by the VoyageAI embeddings API.
+// Documents and queries are embedded with their respective input types.
+type VoyageAI struct {
+	client *voyageai.Client
+	model  voyageai.EmbeddingModel
+}
+
+// NewVoyageAI creates a new VoyageAI embedder using the given client and model.
+func NewVoyageAI(client *voyageai.Client, model voyageai.EmbeddingModel) *VoyageAI {
+	return &VoyageAI{
+		client: client,
+		model:  model,
+	}
+}
+
+// EmbedDocuments embeds the given documents.
+func (e *VoyageAI) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return e.embed(ctx, texts, voyageai.InputTypeDocument)
+}
+
+// EmbedQuery embeds the given search query.
+func (e *VoyageAI) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := e.embed(ctx, []string{text}, voyageai.InputTypeQuery)
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// Dimension returns the dimension of the vectors.
+func (e *VoyageAI) Dimension() int {
+	return voyageDimensions[e.model]
+}
+
+func (e *VoyageAI) embed(ctx context.Context, texts []string, inputType voyageai.InputType) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	resp, err := e.client.EmbedAll(ctx, &voyageai.EmbedRequest{
+		Model:     e.model,
+		Input:     texts,
+		InputType: inputType,
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int, len(resp.Data))
+	vectors := make([][]float32, len(resp.Data))
+	for i, data := range resp.Data {
+		indexes[i] = data.Index
+		vectors[i] = data.Embedding
+	}
+	return ordered(len(texts), indexes, vectors)
+}
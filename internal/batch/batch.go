@@ -0,0 +1,160 @@
+// Package batch splits large requests into batches and runs them concurrently.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// EstimateTokens estimates the number of tokens in s. It assumes one token per three bytes,
+// which overestimates typical text so that batches stay under the token limits.
+func EstimateTokens(s string) int {
+	return (len(s) + 2) / 3
+}
+
+// Split splits n items into consecutive [start, end) ranges of at most maxItems items whose
+// sizes add up to at most maxSize. An item larger than maxSize gets a range of its own.
+// A limit less than or equal to 0 is not enforced.
+func Split(n, maxItems, maxSize int, size func(i int) int) [][2]int {
+	var ranges [][2]int
+	start, total := 0, 0
+	for i := 0; i < n; i++ {
+		s := 0
+		if maxSize > 0 {
+			s = size(i)
+		}
+		if i > start && ((maxItems > 0 && i-start >= maxItems) || (maxSize > 0 && total+s > maxSize)) {
+			ranges = append(ranges, [2]int{start, i})
+			start, total = i, 0
+		}
+		total += s
+	}
+	if start < n {
+		ranges = append(ranges, [2]int{start, n})
+	}
+	return ranges
+}
+
+// Limits limits the size of the batches of texts and the number of batches sent at the same time.
+type Limits struct {
+	// MaxItems is the maximum number of texts per batch.
+	MaxItems int
+	// MaxTokens is the maximum number of estimated tokens per batch.
+	MaxTokens int
+	// Concurrency is the maximum number of batches sent at the same time.
+	Concurrency int
+}
+
+// withDefaults returns the limits with the values that are not set replaced by the defaults.
+func (l Limits) withDefaults(defaults Limits) Limits {
+	if l.MaxItems <= 0 {
+		l.MaxItems = defaults.MaxItems
+	}
+	if l.MaxTokens <= 0 {
+		l.MaxTokens = defaults.MaxTokens
+	}
+	if l.Concurrency <= 0 {
+		l.Concurrency = defaults.Concurrency
+	}
+	return l
+}
+
+// Embed splits the texts into batches within the limits, using the defaults for the limits that are
+// not set, and calls send for the batches concurrently. send returns the response of a batch and its
+// embeddings, and index returns a pointer to the index of an embedding within its batch.
+//
+// Embed checks that each batch returned one embedding per text, rewrites their indexes to positions
+// in texts and returns the embeddings in the order of texts, along with the responses in batch order.
+func Embed[T, R any](ctx context.Context, texts []string, limits, defaults Limits, index func(*T) *int, send func(ctx context.Context, texts []string) (R, []T, error)) ([]T, []R, error) {
+	limits = limits.withDefaults(defaults)
+
+	ranges := Split(len(texts), limits.MaxItems, limits.MaxTokens, func(i int) int {
+		return EstimateTokens(texts[i])
+	})
+	responses := make([]R, len(ranges))
+	embeddings := make([]T, len(texts))
+
+	err := Run(ctx, len(ranges), Options{Concurrency: limits.Concurrency}, func(ctx context.Context, i int) error {
+		start, end := ranges[i][0], ranges[i][1]
+
+		resp, data, err := send(ctx, texts[start:end])
+		if err != nil {
+			return err
+		}
+		if len(data) != end-start {
+			return fmt.Errorf("expected %d embeddings, got %d", end-start, len(data))
+		}
+
+		seen := make([]bool, end-start)
+		for j := range data {
+			idx := index(&data[j])
+			if *idx < 0 || *idx >= end-start || seen[*idx] {
+				return fmt.Errorf("invalid embedding index %d", *idx)
+			}
+			seen[*idx] = true
+			*idx += start
+			embeddings[*idx] = data[j]
+		}
+		responses[i] = resp
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return embeddings, responses, nil
+}
+
+// Options configures how batches are run.
+type Options struct {
+	// Concurrency is the maximum number of batches run at the same time. Defaults to 1.
+	Concurrency int
+}
+
+// Run calls fn for each of the n batches.
+// It stops starting new batches and cancels the context passed to running batches as soon as
+// a batch fails, and returns the first error.
+func Run(ctx context.Context, n int, opts Options, fn func(ctx context.Context, i int) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	sem := make(chan struct{}, concurrency)
+
+loop:
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := fn(ctx, i); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
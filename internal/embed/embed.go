@@ -0,0 +1,46 @@
+// Package embed decodes embedding vectors returned by the embedding APIs.
+package embed
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Decode decodes an embedding encoded either as a JSON array of numbers or as a JSON string
+// holding the base64 encoding of little-endian float32 values.
+func Decode(data []byte) ([]float32, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var vector []float32
+		if err := json.Unmarshal(data, &vector); err != nil {
+			return nil, err
+		}
+		return vector, nil
+	}
+	return DecodeBase64(s)
+}
+
+// DecodeBase64 decodes the base64 encoding of little-endian float32 values.
+func DecodeBase64(s string) ([]float32, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64 embedding: %w", err)
+	}
+	return DecodeFloat32(b)
+}
+
+// DecodeFloat32 decodes little-endian float32 values.
+func DecodeFloat32(b []byte) ([]float32, error) {
+	if len(b)%4 != 0 {
+		return nil, errors.New("invalid base64 embedding: length is not a multiple of 4 bytes")
+	}
+	vector := make([]float32, len(b)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return vector, nil
+}
@@ -0,0 +1,209 @@
+// Package sse implements a reader for server-sent event streams as specified by
+// the HTML Living Standard.
+package sse
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Event is an event dispatched from a server-sent event stream.
+type Event struct {
+	// ID is the last event ID of the stream when the event was dispatched.
+	ID string
+	// Type is the event type, or an empty string for the default "message" type.
+	Type string
+	// Data is the event data. Multiple data fields are joined with a newline.
+	Data []byte
+}
+
+const minBufferSize = 4096
+
+// bom is the UTF-8 byte order mark, which is ignored at the start of a stream.
+var bom = []byte("\xef\xbb\xbf")
+
+// Reader reads events from a server-sent event stream.
+// Lines may be terminated by CRLF, LF or CR, and are not limited in length.
+type Reader struct {
+	rd       io.Reader
+	buf      []byte
+	start    int
+	end      int
+	searched int
+	err      error
+	skipLF   bool
+	started  bool
+
+	eventType   string
+	data        bytes.Buffer
+	hasData     bool
+	lastEventID string
+	retry       time.Duration
+}
+
+// NewReader returns a new Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{
+		rd:  r,
+		buf: make([]byte, minBufferSize),
+	}
+}
+
+// Next reads the next event from the stream. It returns io.EOF when the stream ends.
+// As required by the specification, an incomplete event at the end of the stream is discarded.
+func (r *Reader) Next() (*Event, error) {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) == 0 {
+			if event := r.dispatch(); event != nil {
+				return event, nil
+			}
+			continue
+		}
+		r.processLine(line)
+	}
+}
+
+// LastEventID returns the last event ID set by the stream.
+func (r *Reader) LastEventID() string {
+	return r.lastEventID
+}
+
+// Retry returns the reconnection time set by the stream, or zero if it was not set.
+func (r *Reader) Retry() time.Duration {
+	return r.retry
+}
+
+func (r *Reader) dispatch() *Event {
+	if !r.hasData {
+		r.eventType = ""
+		return nil
+	}
+
+	data := bytes.TrimSuffix(r.data.Bytes(), []byte("\n"))
+	event := &Event{
+		ID:   r.lastEventID,
+		Type: r.eventType,
+		Data: bytes.Clone(data),
+	}
+	if event.Data == nil {
+		event.Data = []byte{}
+	}
+
+	r.eventType = ""
+	r.data.Reset()
+	r.hasData = false
+	return event
+}
+
+func (r *Reader) processLine(line []byte) {
+	if line[0] == ':' {
+		return
+	}
+
+	field, value := line, []byte(nil)
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		field, value = line[:i], line[i+1:]
+		value = bytes.TrimPrefix(value, []byte(" "))
+	}
+
+	switch string(field) {
+	case "event":
+		r.eventType = string(value)
+	case "data":
+		r.data.Write(value)
+		r.data.WriteByte('\n')
+		r.hasData = true
+	case "id":
+		if bytes.IndexByte(value, 0) < 0 {
+			r.lastEventID = string(value)
+		}
+	case "retry":
+		if !isDigits(value) {
+			return
+		}
+		if ms, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+			r.retry = time.Duration(ms) * time.Millisecond
+		}
+	}
+}
+
+// readLine returns the next line without its terminator.
+// The returned slice is only valid until the next call.
+func (r *Reader) readLine() ([]byte, error) {
+	for {
+		if r.skipLF && r.start < r.end {
+			if r.buf[r.start] == '\n' {
+				r.start++
+			}
+			r.skipLF = false
+		}
+		if !r.started {
+			if r.end-r.start < len(bom) && r.err == nil {
+				r.fill()
+				continue
+			}
+			if bytes.HasPrefix(r.buf[r.start:r.end], bom) {
+				r.start += len(bom)
+			}
+			r.started = true
+		}
+
+		if r.start+r.searched < r.end {
+			if i := bytes.IndexAny(r.buf[r.start+r.searched:r.end], "\r\n"); i >= 0 {
+				i += r.start + r.searched
+				line := r.buf[r.start:i]
+				r.skipLF = r.buf[i] == '\r'
+				r.start = i + 1
+				r.searched = 0
+				return line, nil
+			}
+			r.searched = r.end - r.start
+		}
+
+		if r.err != nil {
+			if r.start < r.end {
+				line := r.buf[r.start:r.end]
+				r.start = r.end
+				r.searched = 0
+				return line, nil
+			}
+			return nil, r.err
+		}
+		r.fill()
+	}
+}
+
+func (r *Reader) fill() {
+	if r.start > 0 {
+		copy(r.buf, r.buf[r.start:r.end])
+		r.end -= r.start
+		r.start = 0
+	}
+	if r.end == len(r.buf) {
+		buf := make([]byte, 2*len(r.buf))
+		copy(buf, r.buf[:r.end])
+		r.buf = buf
+	}
+
+	n, err := r.rd.Read(r.buf[r.end:])
+	r.end += n
+	if err != nil {
+		r.err = err
+	}
+}
+
+func isDigits(b []byte) bool {
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return len(b) > 0
+}
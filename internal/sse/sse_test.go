@@ -0,0 +1,233 @@
+package sse
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func readAll(t testing.TB, r io.Reader) []Event {
+	t.Helper()
+
+	var events []Event
+	rd := NewReader(r)
+	for {
+		event, err := rd.Next()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		events = append(events, *event)
+	}
+}
+
+// chunkReader returns the input in chunks of the given sizes, then the rest at once.
+type chunkReader struct {
+	data  []byte
+	sizes []int
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := len(r.data)
+	if len(r.sizes) > 0 {
+		n = min(max(r.sizes[0], 1), n)
+		r.sizes = r.sizes[1:]
+	}
+	n = copy(p, r.data[:n])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestReader(t *testing.T) {
+	long := strings.Repeat("x", 10<<10)
+
+	tests := []struct {
+		name  string
+		input string
+		want  []Event
+	}{
+		{
+			name:  "single event",
+			input: "data: hello\n\n",
+			want:  []Event{{Data: []byte("hello")}},
+		},
+		{
+			name:  "multi-line data",
+			input: "data: a\ndata: b\ndata\n\n",
+			want:  []Event{{Data: []byte("a\nb\n")}},
+		},
+		{
+			name:  "event type",
+			input: "event: ping\ndata: {}\n\ndata: x\n\n",
+			want:  []Event{{Type: "ping", Data: []byte("{}")}, {Data: []byte("x")}},
+		},
+		{
+			name:  "comments",
+			input: ": keep-alive\ndata: a\n:another\n\n: only a comment\n\n",
+			want:  []Event{{Data: []byte("a")}},
+		},
+		{
+			name:  "id field",
+			input: "id: 1\ndata: a\n\ndata: b\n\nid\ndata: c\n\n",
+			want:  []Event{{ID: "1", Data: []byte("a")}, {ID: "1", Data: []byte("b")}, {Data: []byte("c")}},
+		},
+		{
+			name:  "id with null is ignored",
+			input: "id: 1\ndata: a\n\nid: 2\x003\ndata: b\n\n",
+			want:  []Event{{ID: "1", Data: []byte("a")}, {ID: "1", Data: []byte("b")}},
+		},
+		{
+			name:  "crlf",
+			input: "event: e\r\ndata: a\r\ndata: b\r\n\r\n",
+			want:  []Event{{Type: "e", Data: []byte("a\nb")}},
+		},
+		{
+			name:  "cr",
+			input: "data: a\rdata: b\r\rdata: c\r\r",
+			want:  []Event{{Data: []byte("a\nb")}, {Data: []byte("c")}},
+		},
+		{
+			name:  "mixed line endings",
+			input: "data: a\r\n\ndata: b\r\rdata: c\n\r\n",
+			want:  []Event{{Data: []byte("a")}, {Data: []byte("b")}, {Data: []byte("c")}},
+		},
+		{
+			name:  "bom",
+			input: "\xef\xbb\xbfdata: a\n\n",
+			want:  []Event{{Data: []byte("a")}},
+		},
+		{
+			name:  "no space after colon",
+			input: "data:a\ndata:  b\n\n",
+			want:  []Event{{Data: []byte("a\n b")}},
+		},
+		{
+			name:  "empty data",
+			input: "data\n\n",
+			want:  []Event{{Data: []byte{}}},
+		},
+		{
+			name:  "event without data is not dispatched",
+			input: "event: e\n\ndata: a\n\n",
+			want:  []Event{{Data: []byte("a")}},
+		},
+		{
+			name:  "unknown fields are ignored",
+			input: "foo: bar\ndata: a\n\n",
+			want:  []Event{{Data: []byte("a")}},
+		},
+		{
+			name:  "long line",
+			input: "data: " + long + "\n\n",
+			want:  []Event{{Data: []byte(long)}},
+		},
+		{
+			name:  "trailing incomplete event",
+			input: "data: a\n\ndata: b\n",
+			want:  []Event{{Data: []byte("a")}},
+		},
+		{
+			name:  "trailing incomplete line",
+			input: "data: a\n\ndata: b",
+			want:  []Event{{Data: []byte("a")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readers := map[string]io.Reader{
+				"whole":    strings.NewReader(tt.input),
+				"one byte": iotest.OneByteReader(strings.NewReader(tt.input)),
+			}
+			for name, r := range readers {
+				got := readAll(t, r)
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("%s: events = %q, want %q", name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReaderCRSplitAcrossReads(t *testing.T) {
+	// The CR of a CRLF pair ends one read and the LF starts the next,
+	// which must not be read as an extra empty line.
+	r := &chunkReader{data: []byte("data: a\r\ndata: b\r\n\r\n"), sizes: []int{8, 1, 10}}
+	got := readAll(t, r)
+	want := []Event{{Data: []byte("a\nb")}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestReaderRetry(t *testing.T) {
+	rd := NewReader(strings.NewReader("retry: 1500\ndata: a\n\nretry: 2s\n\n"))
+	if _, err := rd.Next(); err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if got := rd.Retry(); got != 1500*time.Millisecond {
+		t.Errorf("Retry() = %v, want %v", got, 1500*time.Millisecond)
+	}
+	if _, err := rd.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("Next() error = %v, want io.EOF", err)
+	}
+	if got := rd.Retry(); got != 1500*time.Millisecond {
+		t.Errorf("Retry() after invalid value = %v, want %v", got, 1500*time.Millisecond)
+	}
+}
+
+func TestReaderLastEventID(t *testing.T) {
+	rd := NewReader(strings.NewReader("id: 7\n\n"))
+	if _, err := rd.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("Next() error = %v, want io.EOF", err)
+	}
+	if got := rd.LastEventID(); got != "7" {
+		t.Errorf("LastEventID() = %q, want %q", got, "7")
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	errRead := errors.New("read failed")
+	rd := NewReader(io.MultiReader(strings.NewReader("data: a\n\ndata: b\n"), iotest.ErrReader(errRead)))
+	if _, err := rd.Next(); err != nil {
+		t.Fatalf("Next() error = %v", err)
+	}
+	if _, err := rd.Next(); !errors.Is(err, errRead) {
+		t.Errorf("Next() error = %v, want %v", err, errRead)
+	}
+}
+
+func FuzzReader(f *testing.F) {
+	f.Add([]byte("data: a\n\n"), []byte{1})
+	f.Add([]byte("\xef\xbb\xbfid: 1\r\nretry: 10\r\ndata: a\r\ndata: b\r\n\r\n"), []byte{1, 2, 3})
+	f.Add([]byte(": comment\rdata: a\r\rdata: b\n"), []byte{7, 1})
+	f.Add([]byte("event: e\ndata:\n\ndata: x\r"), []byte{})
+
+	f.Fuzz(func(t *testing.T, input, chunks []byte) {
+		want := readAll(t, bytes.NewReader(input))
+
+		sizes := make([]int, len(chunks))
+		for i, c := range chunks {
+			sizes[i] = int(c)
+		}
+		got := readAll(t, &chunkReader{data: input, sizes: sizes})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("chunked events = %q, want %q", got, want)
+		}
+
+		got = readAll(t, iotest.OneByteReader(bytes.NewReader(input)))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("one byte events = %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/joeychilson/ai/anthropic"
+)
+
+// DefaultAnthropicMaxTokens is the maximum number of tokens used when a request does not set one,
+// since the Anthropic API requires it.
+const DefaultAnthropicMaxTokens = 1024
+
+// Anthropic is a ChatModel backed by the Anthropic messages API.
+type Anthropic struct {
+	client *anthropic.Client
+	model  anthropic.LanguageModel
+}
+
+// NewAnthropic creates a new Anthropic chat model using the given client and model.
+func NewAnthropic(client *anthropic.Client, model anthropic.LanguageModel) *Anthropic {
+	return &Anthropic{
+		client: client,
+		model:  model,
+	}
+}
+
+// Chat sends the conversation to Anthropic and returns the next message.
+func (m *Anthropic) Chat(ctx context.Context, req *Request) (*Response, error) {
+	system, messages, err := toAnthropicMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultAnthropicMaxTokens
+	}
+
+	chatReq := &anthropic.ChatRequest{
+		Model:         m.model,
+		Messages:      messages,
+		System:        system,
+		MaxTokens:     maxTokens,
+		StopSequences: req.Stop,
+		Temperature:   req.Temperature,
+		TopP:          req.TopP,
+	}
+	for _, tool := range req.Tools {
+		chatReq.Tools = append(chatReq.Tools, anthropic.Tool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: tool.Parameters,
+		})
+	}
+
+	resp, err := m.client.Chat(ctx, chatReq)
+	if err != nil {
+		return nil, err
+	}
+
+	message := Message{Role: RoleAssistant}
+	for _, content := range resp.Content {
+		switch c := content.(type) {
+		case anthropic.TextContent:
+			message.Content = append(message.Content, TextContent{Text: c.Text})
+		case anthropic.ToolUseContent:
+			message.Content = append(message.Content, ToolCallContent{
+				ID:        c.ID,
+				Name:      c.Name,
+				Arguments: c.Input,
+			})
+		}
+	}
+
+	return &Response{
+		Message:    message,
+		StopReason: StopReason(resp.StopReason),
+		Usage: Usage{
+			InputTokens:  resp.Usage.InputTokens,
+			OutputTokens: resp.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+// toAnthropicMessages converts the messages, moving system messages to the system prompt and
+// merging consecutive messages of the same role, since Anthropic requires alternating roles.
+func toAnthropicMessages(messages []Message) (string, []anthropic.Message, error) {
+	var system []string
+	var out []anthropic.Message
+
+	appendContent := func(role anthropic.Role, content []anthropic.Content) {
+		if len(out) > 0 && out[len(out)-1].Role() == role {
+			switch last := out[len(out)-1].(type) {
+			case anthropic.UserMessage:
+				last.Content = append(last.Content, content...)
+				out[len(out)-1] = last
+			case anthropic.AssistantMessage:
+				last.Content = append(last.Content, content...)
+				out[len(out)-1] = last
+			}
+			return
+		}
+		if role == anthropic.RoleAssistant {
+			out = append(out, anthropic.AssistantMessage{Content: content})
+		} else {
+			out = append(out, anthropic.UserMessage{Content: content})
+		}
+	}
+
+	for _, message := range messages {
+		if message.Role == RoleSystem {
+			system = append(system, message.Text())
+			continue
+		}
+
+		role := anthropic.RoleUser
+		switch message.Role {
+		case RoleAssistant:
+			role = anthropic.RoleAssistant
+		case RoleUser, RoleTool:
+		default:
+			return "", nil, fmt.Errorf("llm: unsupported role %s", message.Role)
+		}
+
+		var content []anthropic.Content
+		for _, c := range message.Content {
+			converted, err := toAnthropicContent(c)
+			if err != nil {
+				return "", nil, fmt.Errorf("llm: %s message: %w", message.Role, err)
+			}
+			content = append(content, converted)
+		}
+		appendContent(role, content)
+	}
+	return strings.Join(system, "\n\n"), out, nil
+}
+
+func toAnthropicContent(content Content) (anthropic.Content, error) {
+	switch c := content.(type) {
+	case TextContent:
+		return anthropic.TextContent{Text: c.Text}, nil
+	case ImageContent:
+		if c.URL != "" {
+			return nil, fmt.Errorf("image urls are not supported by anthropic")
+		}
+		return anthropic.ImageContent{
+			Source: anthropic.ImageSource{
+				Type:      "base64",
+				MediaType: imageMediaType(c),
+				Data:      base64.StdEncoding.EncodeToString(c.Data),
+			},
+		}, nil
+	case ToolCallContent:
+		return anthropic.ToolUseContent{ID: c.ID, Name: c.Name, Input: c.Arguments}, nil
+	case ToolResultContent:
+		result := anthropic.ToolResultContent{ToolUseID: c.ToolCallID, IsError: c.IsError}
+		if c.Content != "" {
+			result.Content = []anthropic.Content{anthropic.TextContent{Text: c.Content}}
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unsupported %s content", content.Type())
+	}
+}
@@ -0,0 +1,170 @@
+// Package llm defines a provider-neutral chat interface, so application code can switch
+// between the OpenAI and Anthropic chat models through configuration.
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// ChatModel is a model that generates the next message of a conversation.
+type ChatModel interface {
+	Chat(ctx context.Context, req *Request) (*Response, error)
+}
+
+// Role represents the role of a message author.
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+// Content represents the content of a message.
+type Content interface {
+	Type() string
+}
+
+// TextContent represents a text content.
+type TextContent struct {
+	Text string
+}
+
+// Type returns the type of the text content.
+func (c TextContent) Type() string {
+	return "text"
+}
+
+// ImageContent represents an image, either as raw data or as a URL.
+// If MediaType is empty for raw data, it is detected from the data.
+type ImageContent struct {
+	MediaType string
+	Data      []byte
+	URL       string
+}
+
+// Type returns the type of the image content.
+func (c ImageContent) Type() string {
+	return "image"
+}
+
+// ToolCallContent represents a request from the assistant to call a tool.
+type ToolCallContent struct {
+	ID        string
+	Name      string
+	Arguments json.RawMessage
+}
+
+// Type returns the type of the tool call content.
+func (c ToolCallContent) Type() string {
+	return "tool_call"
+}
+
+// ToolResultContent represents the result of a tool call.
+type ToolResultContent struct {
+	ToolCallID string
+	Content    string
+	IsError    bool
+}
+
+// Type returns the type of the tool result content.
+func (c ToolResultContent) Type() string {
+	return "tool_result"
+}
+
+// Message represents a message in the conversation.
+type Message struct {
+	Role    Role
+	Content []Content
+}
+
+// SystemMessage creates a system message with the given text.
+func SystemMessage(text string) Message {
+	return Message{Role: RoleSystem, Content: []Content{TextContent{Text: text}}}
+}
+
+// UserMessage creates a user message with the given text.
+func UserMessage(text string) Message {
+	return Message{Role: RoleUser, Content: []Content{TextContent{Text: text}}}
+}
+
+// AssistantMessage creates an assistant message with the given text.
+func AssistantMessage(text string) Message {
+	return Message{Role: RoleAssistant, Content: []Content{TextContent{Text: text}}}
+}
+
+// ToolMessage creates a tool message with the given tool results.
+func ToolMessage(results ...ToolResultContent) Message {
+	content := make([]Content, len(results))
+	for i, result := range results {
+		content[i] = result
+	}
+	return Message{Role: RoleTool, Content: content}
+}
+
+// Text returns the concatenated text of the text content of the message.
+func (m Message) Text() string {
+	var text strings.Builder
+	for _, content := range m.Content {
+		if c, ok := content.(TextContent); ok {
+			text.WriteString(c.Text)
+		}
+	}
+	return text.String()
+}
+
+// ToolCalls returns the tool calls of the message.
+func (m Message) ToolCalls() []ToolCallContent {
+	var toolCalls []ToolCallContent
+	for _, content := range m.Content {
+		if c, ok := content.(ToolCallContent); ok {
+			toolCalls = append(toolCalls, c)
+		}
+	}
+	return toolCalls
+}
+
+// Tool describes a tool the model may call.
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON schema of the tool arguments, such as a *schema.Schema.
+	Parameters any
+}
+
+// Request describes a chat request.
+type Request struct {
+	Messages    []Message
+	Tools       []Tool
+	MaxTokens   int
+	Temperature float32
+	TopP        float32
+	Stop        []string
+}
+
+// StopReason represents why the model stopped generating.
+type StopReason string
+
+const (
+	StopReasonEndTurn       StopReason = "end_turn"
+	StopReasonMaxTokens     StopReason = "max_tokens"
+	StopReasonStopSequence  StopReason = "stop_sequence"
+	StopReasonToolUse       StopReason = "tool_use"
+	StopReasonContentFilter StopReason = "content_filter"
+)
+
+// Usage describes the token usage of a request.
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// Response describes a chat response.
+type Response struct {
+	Message    Message
+	StopReason StopReason
+	Usage      Usage
+}
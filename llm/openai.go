@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/joeychilson/ai/openai"
+)
+
+// OpenAI is a ChatModel backed by the OpenAI chat completions API.
+type OpenAI struct {
+	client *openai.Client
+	model  openai.LanguageModel
+}
+
+// NewOpenAI creates a new OpenAI chat model using the given client and model.
+func NewOpenAI(client *openai.Client, model openai.LanguageModel) *OpenAI {
+	return &OpenAI{
+		client: client,
+		model:  model,
+	}
+}
+
+// Chat sends the conversation to OpenAI and returns the next message.
+func (m *OpenAI) Chat(ctx context.Context, req *Request) (*Response, error) {
+	messages, err := toOpenAIMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	chatReq := &openai.ChatRequest{
+		Model:       m.model,
+		Messages:    messages,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        req.Stop,
+	}
+	for _, tool := range req.Tools {
+		chatReq.Tools = append(chatReq.Tools, openai.NewFunctionTool(tool.Name, tool.Description, tool.Parameters))
+	}
+
+	resp, err := m.client.Chat(ctx, chatReq)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("llm: openai response has no choices")
+	}
+
+	choice := resp.Choices[0]
+	message := Message{Role: RoleAssistant}
+	if choice.Message.Content != "" {
+		message.Content = append(message.Content, TextContent{Text: choice.Message.Content})
+	}
+	for _, toolCall := range choice.Message.ToolCalls {
+		message.Content = append(message.Content, ToolCallContent{
+			ID:        toolCall.ID,
+			Name:      toolCall.Function.Name,
+			Arguments: json.RawMessage(toolCall.Function.Arguments),
+		})
+	}
+
+	return &Response{
+		Message:    message,
+		StopReason: fromOpenAIFinishReason(choice.FinishReason),
+		Usage: Usage{
+			InputTokens:  resp.Usage.PromptTokens,
+			OutputTokens: resp.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+func toOpenAIMessages(messages []Message) ([]openai.Message, error) {
+	var out []openai.Message
+	for _, message := range messages {
+		switch message.Role {
+		case RoleSystem:
+			out = append(out, openai.SystemMessage{Content: message.Text()})
+		case RoleAssistant:
+			assistant := openai.AssistantMessage{Content: message.Text()}
+			for _, toolCall := range message.ToolCalls() {
+				arguments := string(toolCall.Arguments)
+				if arguments == "" {
+					arguments = "{}"
+				}
+				assistant.ToolCalls = append(assistant.ToolCalls, openai.ToolCall{
+					ID:       toolCall.ID,
+					Type:     "function",
+					Function: openai.FunctionCall{Name: toolCall.Name, Arguments: arguments},
+				})
+			}
+			out = append(out, assistant)
+		case RoleUser, RoleTool:
+			// Tool results are separate messages in OpenAI, which must directly
+			// follow the assistant message with the tool calls.
+			var content []openai.Content
+			for _, c := range message.Content {
+				switch c := c.(type) {
+				case ToolResultContent:
+					result := c.Content
+					if c.IsError {
+						result = "error: " + result
+					}
+					out = append(out, openai.ToolMessage{Content: result, ToolCallID: c.ToolCallID})
+				case TextContent:
+					content = append(content, openai.TextContent{Text: c.Text})
+				case ImageContent:
+					content = append(content, openai.ImageContent{URL: imageURL(c)})
+				default:
+					return nil, fmt.Errorf("llm: unsupported %s content in %s message", c.Type(), message.Role)
+				}
+			}
+			if len(content) > 0 {
+				out = append(out, openai.UserMessage{Content: content})
+			}
+		default:
+			return nil, fmt.Errorf("llm: unsupported role %s", message.Role)
+		}
+	}
+	return out, nil
+}
+
+// imageURL returns the URL of the image, encoding raw data as a data URL.
+func imageURL(image ImageContent) string {
+	if image.URL != "" {
+		return image.URL
+	}
+	return "data:" + imageMediaType(image) + ";base64," + base64.StdEncoding.EncodeToString(image.Data)
+}
+
+// imageMediaType returns the media type of the image data, detecting it if not set.
+func imageMediaType(image ImageContent) string {
+	if image.MediaType != "" {
+		return image.MediaType
+	}
+	mediaType, _, _ := strings.Cut(http.DetectContentType(image.Data), ";")
+	return mediaType
+}
+
+func fromOpenAIFinishReason(reason string) StopReason {
+	switch reason {
+	case "stop":
+		return StopReasonEndTurn
+	case "length":
+		return StopReasonMaxTokens
+	case "tool_calls", "function_call":
+		return StopReasonToolUse
+	case "content_filter":
+		return StopReasonContentFilter
+	default:
+		return StopReason(reason)
+	}
+}
@@ -0,0 +1,128 @@
+package openai
+
+import (
+	"sort"
+	"strings"
+)
+
+// ChunkAccumulator rebuilds a ChatResponse from the chunks of a streamed chat completion.
+//
+// The content of each choice is joined, tool call fragments are assembled per index,
+// and the usage is taken from the final chunk when StreamOptions.IncludeUsage is enabled.
+type ChunkAccumulator struct {
+	response ChatResponse
+	choices  map[int]*choiceAccumulator
+}
+
+type choiceAccumulator struct {
+	choice    ResponseChoice
+	content   strings.Builder
+	refusal   strings.Builder
+	toolCalls map[int]*toolCallAccumulator
+}
+
+type toolCallAccumulator struct {
+	toolCall  ToolCall
+	arguments strings.Builder
+}
+
+// Add adds a chunk to the accumulated response.
+func (a *ChunkAccumulator) Add(chunk *ChatChunk) {
+	if a.choices == nil {
+		a.choices = make(map[int]*choiceAccumulator)
+	}
+
+	if chunk.ID != "" {
+		a.response.ID = chunk.ID
+	}
+	if chunk.Created != 0 {
+		a.response.Created = int(chunk.Created)
+	}
+	if chunk.Model != "" {
+		a.response.Model = chunk.Model
+	}
+	if chunk.SystemFingerprint != "" {
+		a.response.SystemFingerprint = chunk.SystemFingerprint
+	}
+	if chunk.Usage != nil {
+		a.response.Usage = *chunk.Usage
+	}
+
+	for _, choice := range chunk.Choices {
+		acc, ok := a.choices[choice.Index]
+		if !ok {
+			acc = &choiceAccumulator{
+				choice:    ResponseChoice{Index: choice.Index},
+				toolCalls: make(map[int]*toolCallAccumulator),
+			}
+			a.choices[choice.Index] = acc
+		}
+
+		if choice.Delta.Role != "" {
+			acc.choice.Message.Role = choice.Delta.Role
+		}
+		acc.content.WriteString(choice.Delta.Content)
+		acc.refusal.WriteString(choice.Delta.Refusal)
+		if choice.FinishReason != "" {
+			acc.choice.FinishReason = choice.FinishReason
+		}
+		if choice.LogProbs != nil {
+			if acc.choice.LogProbs == nil {
+				acc.choice.LogProbs = &LogProbs{}
+			}
+			acc.choice.LogProbs.Content = append(acc.choice.LogProbs.Content, choice.LogProbs.Content...)
+		}
+
+		for _, delta := range choice.Delta.ToolCalls {
+			toolCall, ok := acc.toolCalls[delta.Index]
+			if !ok {
+				toolCall = &toolCallAccumulator{}
+				acc.toolCalls[delta.Index] = toolCall
+			}
+			if delta.ID != "" {
+				toolCall.toolCall.ID = delta.ID
+			}
+			if delta.Type != "" {
+				toolCall.toolCall.Type = delta.Type
+			}
+			if delta.Function.Name != "" {
+				toolCall.toolCall.Function.Name = delta.Function.Name
+			}
+			toolCall.arguments.WriteString(delta.Function.Arguments)
+		}
+	}
+}
+
+// Response returns the accumulated response with its choices ordered by index.
+func (a *ChunkAccumulator) Response() *ChatResponse {
+	response := a.response
+	response.Object = "chat.completion"
+	response.Choices = make([]ResponseChoice, 0, len(a.choices))
+
+	for _, acc := range a.choices {
+		choice := acc.choice
+		choice.Message.Content = acc.content.String()
+		choice.Message.Refusal = acc.refusal.String()
+		if choice.Message.Role == "" {
+			choice.Message.Role = string(RoleAssistant)
+		}
+
+		indexes := make([]int, 0, len(acc.toolCalls))
+		for index := range acc.toolCalls {
+			indexes = append(indexes, index)
+		}
+		sort.Ints(indexes)
+
+		for _, index := range indexes {
+			toolCall := acc.toolCalls[index].toolCall
+			toolCall.Function.Arguments = acc.toolCalls[index].arguments.String()
+			choice.Message.ToolCalls = append(choice.Message.ToolCalls, toolCall)
+		}
+		response.Choices = append(response.Choices, choice)
+	}
+
+	sort.Slice(response.Choices, func(i, j int) bool {
+		return response.Choices[i].Index < response.Choices[j].Index
+	})
+	return &response
+}
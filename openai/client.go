@@ -1,7 +1,6 @@
 package openai
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -11,6 +10,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/joeychilson/ai/internal/embed"
+	"github.com/joeychilson/ai/retry"
 )
 
 const (
@@ -19,18 +22,33 @@ const (
 
 // Client is a client for the OpenAI API.
 type Client struct {
-	baseURL    string
-	token      string
-	httpClient *http.Client
+	baseURL     string
+	token       string
+	httpClient  *http.Client
+	headers     http.Header
+	userAgent   string
+	timeout     time.Duration
+	retryPolicy retry.Policy
 }
 
-// New creates a new Client using the given token.
-func New(token string) *Client {
-	return &Client{
-		baseURL:    defaultBaseURL,
-		token:      token,
-		httpClient: http.DefaultClient,
+// New creates a new Client using the given token and options.
+func New(token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:     defaultBaseURL,
+		token:       token,
+		httpClient:  http.DefaultClient,
+		headers:     make(http.Header),
+		retryPolicy: retry.DefaultPolicy(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.timeout > 0 {
+		httpClient := *c.httpClient
+		httpClient.Timeout = c.timeout
+		c.httpClient = &httpClient
 	}
+	return c
 }
 
 // SpeechModel represents the Speech model to use for the request.
@@ -472,87 +490,210 @@ func (m ToolMessage) MarshalJSON() ([]byte, error) {
 	})
 }
 
+// FunctionDefinition describes a function the model may call.
+type FunctionDefinition struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Parameters is the JSON schema of the function arguments.
+	Parameters any `json:"parameters,omitempty"`
+	// Strict enables strict schema adherence, which requires every property to be required
+	// and additional properties to be disallowed.
+	Strict bool `json:"strict,omitempty"`
+}
+
 // Tool represents a tool in the chat.
 type Tool struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Parameters  map[string]any `json:"parameters"`
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+// NewFunctionTool creates a function tool with the given name, description and parameters schema.
+func NewFunctionTool(name, description string, parameters any) Tool {
+	return Tool{
+		Type: "function",
+		Function: FunctionDefinition{
+			Name:        name,
+			Description: description,
+			Parameters:  parameters,
+		},
+	}
+}
+
+// MarshalJSON marshals the tool to JSON, defaulting the type to function.
+func (t Tool) MarshalJSON() ([]byte, error) {
+	toolType := t.Type
+	if toolType == "" {
+		toolType = "function"
+	}
+	return json.Marshal(struct {
+		Type     string             `json:"type"`
+		Function FunctionDefinition `json:"function"`
+	}{
+		Type:     toolType,
+		Function: t.Function,
+	})
+}
+
+// FunctionCall represents the function called by a tool call.
+type FunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 // ToolCall represents a tool call in the chat.
 type ToolCall struct {
-	ID       string `json:"id"`
-	Type     string `json:"type"`
-	Function struct {
-		Name      string `json:"name"`
-		Arguments string `json:"arguments"`
-	} `json:"function"`
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
 }
 
+// ToolChoiceMode represents how the model should use the provided tools.
+type ToolChoiceMode string
+
+const (
+	// ToolChoiceAuto lets the model decide whether to call tools.
+	ToolChoiceAuto ToolChoiceMode = "auto"
+	// ToolChoiceNone prevents the model from calling tools.
+	ToolChoiceNone ToolChoiceMode = "none"
+	// ToolChoiceRequired forces the model to call one or more tools.
+	ToolChoiceRequired ToolChoiceMode = "required"
+)
+
 // ToolChoice represents a tool choice in the chat.
+// If Function is set, the model is forced to call the named function and Mode is ignored.
+// If neither is set, the mode defaults to ToolChoiceAuto.
 type ToolChoice struct {
-	Type     string `json:"type"`
-	Function struct {
-		Name string `json:"name"`
-	} `json:"function"`
+	Mode     ToolChoiceMode
+	Function string
+}
+
+// MarshalJSON marshals the tool choice to JSON.
+func (c ToolChoice) MarshalJSON() ([]byte, error) {
+	if c.Function == "" {
+		if c.Mode == "" {
+			return json.Marshal(ToolChoiceAuto)
+		}
+		return json.Marshal(c.Mode)
+	}
+	return json.Marshal(struct {
+		Type     string `json:"type"`
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}{
+		Type: "function",
+		Function: struct {
+			Name string `json:"name"`
+		}{Name: c.Function},
+	})
+}
+
+// TopLogProb describes the log probability of one of the most likely tokens.
+type TopLogProb struct {
+	Token   string  `json:"token"`
+	LogProb float32 `json:"logprob"`
+	Bytes   []int   `json:"bytes"`
 }
 
 // LogProb describes a log probability.
 type LogProb struct {
-	Token       string  `json:"token"`
-	LogProb     float32 `json:"logprob"`
-	Bytes       []byte  `json:"bytes"`
-	TopLogProbs []struct {
-		Token   string  `json:"token"`
-		LogProb float32 `json:"logprob"`
-		Bytes   []byte  `json:"bytes"`
-	} `json:"top_logprobs"`
+	Token       string       `json:"token"`
+	LogProb     float32      `json:"logprob"`
+	Bytes       []int        `json:"bytes"`
+	TopLogProbs []TopLogProb `json:"top_logprobs"`
+}
+
+// LogProbs describes the log probabilities of the tokens of a choice.
+type LogProbs struct {
+	Content []LogProb `json:"content"`
+}
+
+// Usage describes the token usage of a request.
+type Usage struct {
+	CompletionTokens int `json:"completion_tokens"`
+	PromptTokens     int `json:"prompt_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ResponseMessage describes the message of a chat completion choice.
+type ResponseMessage struct {
+	Content   string     `json:"content"`
+	Refusal   string     `json:"refusal"`
+	ToolCalls []ToolCall `json:"tool_calls"`
+	Role      string     `json:"role"`
+}
+
+// ResponseChoice describes a chat completion choice.
+type ResponseChoice struct {
+	FinishReason string          `json:"finish_reason"`
+	Index        int             `json:"index"`
+	Message      ResponseMessage `json:"message"`
+	LogProbs     *LogProbs       `json:"logprobs"`
 }
 
 // ChatResponse describes a chat completion response.
 type ChatResponse struct {
-	ID      string `json:"id"`
-	Choices []struct {
-		FinishReason string `json:"finish_reason"`
-		Index        int    `json:"index"`
-		Message      struct {
-			Content   string     `json:"content"`
-			ToolCalls []ToolCall `json:"tool_calls"`
-			Role      string     `json:"role"`
-		} `json:"message"`
-		LogProbs []LogProb `json:"logprobs"`
-	} `json:"choices"`
-	Created           int    `json:"created"`
-	Model             string `json:"model"`
-	SystemFingerprint string `json:"system_fingerprint"`
-	Object            string `json:"object"`
-	Usage             struct {
-		CompletionTokens int `json:"completion_tokens"`
-		PromptTokens     int `json:"prompt_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	ID                string           `json:"id"`
+	Choices           []ResponseChoice `json:"choices"`
+	Created           int              `json:"created"`
+	Model             string           `json:"model"`
+	SystemFingerprint string           `json:"system_fingerprint"`
+	Object            string           `json:"object"`
+	Usage             Usage            `json:"usage"`
+}
+
+// StreamOptions describes options for streamed chat completions.
+type StreamOptions struct {
+	// IncludeUsage requests a final chunk with the token usage of the whole request.
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// ResponseFormatType represents the format of the model output.
+type ResponseFormatType string
+
+const (
+	ResponseFormatText       ResponseFormatType = "text"
+	ResponseFormatJSONObject ResponseFormatType = "json_object"
+	ResponseFormatJSONSchema ResponseFormatType = "json_schema"
+)
+
+// JSONSchema describes the JSON schema of a structured output.
+type JSONSchema struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      any    `json:"schema,omitempty"`
+	Strict      bool   `json:"strict,omitempty"`
+}
+
+// ResponseFormat describes the format of the model output.
+// JSONSchema is only used with the json_schema type.
+type ResponseFormat struct {
+	Type       ResponseFormatType `json:"type"`
+	JSONSchema *JSONSchema        `json:"json_schema,omitempty"`
 }
 
 // ChatRequest describes a chat completion request.
 type ChatRequest struct {
-	Messages         []Message     `json:"messages"`
-	Model            LanguageModel `json:"model"`
-	FrequencyPenalty float32       `json:"frequency_penalty,omitempty"`
-	LogitBias        float32       `json:"logit_bias,omitempty"`
-	LogProbs         bool          `json:"logprobs,omitempty"`
-	TopLogProbs      int           `json:"top_logprobs,omitempty"`
-	MaxTokens        int           `json:"max_tokens,omitempty"`
-	N                int           `json:"n,omitempty"`
-	PresencePenalty  float32       `json:"presence_penalty,omitempty"`
-	ResponseFormat   string        `json:"response_format,omitempty"` // TODO
-	Seed             int           `json:"seed,omitempty"`
-	Stop             []string      `json:"stop,omitempty"`
-	Stream           bool          `json:"stream,omitempty"`
-	Temperature      float32       `json:"temperature,omitempty"`
-	TopP             float32       `json:"top_p,omitempty"`
-	Tools            []Tool        `json:"tools,omitempty"`
-	ToolChoices      []ToolChoice  `json:"tool_choices,omitempty"`
-	User             string        `json:"user,omitempty"`
+	Messages          []Message       `json:"messages"`
+	Model             LanguageModel   `json:"model"`
+	FrequencyPenalty  float32         `json:"frequency_penalty,omitempty"`
+	LogitBias         float32         `json:"logit_bias,omitempty"`
+	LogProbs          bool            `json:"logprobs,omitempty"`
+	TopLogProbs       int             `json:"top_logprobs,omitempty"`
+	MaxTokens         int             `json:"max_tokens,omitempty"`
+	N                 int             `json:"n,omitempty"`
+	PresencePenalty   float32         `json:"presence_penalty,omitempty"`
+	ResponseFormat    *ResponseFormat `json:"response_format,omitempty"`
+	Seed              int             `json:"seed,omitempty"`
+	Stop              []string        `json:"stop,omitempty"`
+	Stream            bool            `json:"stream,omitempty"`
+	StreamOptions     *StreamOptions  `json:"stream_options,omitempty"`
+	Temperature       float32         `json:"temperature,omitempty"`
+	TopP              float32         `json:"top_p,omitempty"`
+	Tools             []Tool          `json:"tools,omitempty"`
+	ToolChoice        *ToolChoice     `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool           `json:"parallel_tool_calls,omitempty"`
+	User              string          `json:"user,omitempty"`
 }
 
 // Chat performs a chat completion request and returns the completion.
@@ -580,95 +721,67 @@ func (c *Client) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, err
 
 // ChatChunk represents a chat chunk in the stream chat completion.
 type ChatChunk struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
+	ID                string   `json:"id"`
+	Object            string   `json:"object"`
+	Created           int64    `json:"created"`
+	Model             string   `json:"model"`
+	SystemFingerprint string   `json:"system_fingerprint"`
+	Choices           []Choice `json:"choices"`
+	// Usage is only set on the final chunk when StreamOptions.IncludeUsage is enabled.
+	Usage *Usage `json:"usage"`
 }
 
 // Choice represents a chat completion chunk choice in the stream chat completion.
 type Choice struct {
-	Delta        Delta  `json:"delta"`
-	FinishReason string `json:"finish_reason"`
-	Index        int    `json:"index"`
+	Delta        Delta     `json:"delta"`
+	FinishReason string    `json:"finish_reason"`
+	Index        int       `json:"index"`
+	LogProbs     *LogProbs `json:"logprobs"`
+}
+
+// ToolCallDelta represents a fragment of a tool call in the stream chat completion.
+// Fragments of the same tool call share the same index.
+type ToolCallDelta struct {
+	Index    int          `json:"index"`
+	ID       string       `json:"id"`
+	Type     string       `json:"type"`
+	Function FunctionCall `json:"function"`
 }
 
 // Delta represents a streaming delta in the stream chat completion.
 type Delta struct {
-	Content string `json:"content"`
-	Role    string `json:"role"`
+	Content   string          `json:"content"`
+	Refusal   string          `json:"refusal"`
+	Role      string          `json:"role"`
+	ToolCalls []ToolCallDelta `json:"tool_calls"`
 }
 
 // StreamCallback is a callback function for streaming chat completion.
 type StreamCallback func(ctx context.Context, chunk *ChatChunk)
 
 // ChatStream performs a chat completion request and streams the completion to the callback.
+// The callback is called once for each choice of a chunk, and once for chunks without choices,
+// such as the final usage chunk.
 func (c *Client) ChatStream(ctx context.Context, req *ChatRequest, callback StreamCallback) error {
-	req.Stream = true
-
-	url := fmt.Sprintf("%s/chat/completions", c.baseURL)
-
-	resp, err := c.requestJSON(ctx, url, req)
+	stream, err := c.NewChatStream(ctx, req)
 	if err != nil {
-		return fmt.Errorf("failed to perform request: %v", err)
+		return err
 	}
-	defer resp.Body.Close()
+	defer stream.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return c.decodeError(resp)
-	}
-
-	reader := bufio.NewReader(resp.Body)
-	for {
-		line, err := reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				break
-			}
-			return fmt.Errorf("failed to read response: %v", err)
-		}
-
-		line = bytes.TrimSpace(line)
-		if len(line) == 0 {
+	for stream.Next() {
+		event := stream.Current()
+		if len(event.Choices) == 0 {
+			callback(ctx, event)
 			continue
 		}
-
-		line = bytes.TrimPrefix(line, []byte("data: "))
-
-		var event struct {
-			ID      string   `json:"id"`
-			Object  string   `json:"object"`
-			Created int64    `json:"created"`
-			Model   string   `json:"model"`
-			Choices []Choice `json:"choices"`
-		}
-		if err := json.Unmarshal(line, &event); err != nil {
-			return fmt.Errorf("failed to unmarshal event: %v", err)
-		}
-
 		for _, choice := range event.Choices {
-			chunk := &ChatChunk{
-				ID:      event.ID,
-				Object:  event.Object,
-				Created: event.Created,
-				Model:   event.Model,
-				Choices: []Choice{
-					{
-						Delta:        choice.Delta,
-						FinishReason: choice.FinishReason,
-						Index:        choice.Index,
-					},
-				},
-			}
-			callback(ctx, chunk)
-
-			if choice.FinishReason != "" {
-				return nil
-			}
+			chunk := *event
+			chunk.Choices = []Choice{choice}
+			callback(ctx, &chunk)
 		}
 	}
-	return nil
+	return stream.Err()
 }
 
 // EmbeddingModel represents the Embedding model to use for the request.
@@ -695,15 +808,38 @@ type Embedding struct {
 	Embedding []float32 `json:"embedding"`
 }
 
+// UnmarshalJSON unmarshals the embedding from JSON, decoding base64 encoded vectors.
+func (e *Embedding) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Object    string          `json:"object"`
+		Index     int             `json:"index"`
+		Embedding json.RawMessage `json:"embedding"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	vector, err := embed.Decode(raw.Embedding)
+	if err != nil {
+		return err
+	}
+	e.Object = raw.Object
+	e.Index = raw.Index
+	e.Embedding = vector
+	return nil
+}
+
 // EmbedResponse describes an embedding response.
 type EmbedResponse struct {
 	Object string      `json:"object"`
 	Data   []Embedding `json:"data"`
 	Model  string      `json:"model"`
-	Usage  struct {
-		PromptTokens int `json:"prompt_tokens"`
-		TotalTokens  int `json:"total_tokens"`
-	} `json:"usage"`
+	Usage  EmbedUsage  `json:"usage"`
+}
+
+// EmbedUsage describes the token usage of an embedding request.
+type EmbedUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
 }
 
 // EmbedRequest describes an embedding request.
@@ -716,10 +852,17 @@ type EmbedRequest struct {
 }
 
 // Embed performs an embedding request and returns the embeddings.
+// Embeddings are requested in base64 unless an encoding format is set, since it is much smaller
+// on the wire, and are always decoded to float32 values.
 func (c *Client) Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error) {
 	url := fmt.Sprintf("%s/embeddings", c.baseURL)
 
-	resp, err := c.requestJSON(ctx, url, req)
+	embedReq := *req
+	if embedReq.EncodingFormat == "" {
+		embedReq.EncodingFormat = FormatBase64
+	}
+
+	resp, err := c.requestJSON(ctx, url, &embedReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform request: %v", err)
 	}
@@ -916,9 +1059,8 @@ func (c *Client) ListModels(ctx context.Context) (*ListModelsResponse, error) {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.token)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform request: %v", err)
 	}
@@ -945,9 +1087,8 @@ func (c *Client) RetrieveModel(ctx context.Context, id string) (*Model, error) {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.token)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to perform request: %v", err)
 	}
@@ -976,9 +1117,8 @@ func (c *Client) requestJSON(ctx context.Context, url string, req any) (*http.Re
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+c.token)
 
-	return c.httpClient.Do(httpReq)
+	return c.do(httpReq)
 }
 
 // MultipartFormDataRequest is an interface for requests that require multipart form data.
@@ -1006,9 +1146,19 @@ func (c *Client) requestMultipartFormData(ctx context.Context, url string, req M
 	}
 
 	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
-	httpReq.Header.Set("Authorization", "Bearer "+c.token)
 
-	return c.httpClient.Do(httpReq)
+	return c.do(httpReq)
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	for key, values := range c.headers {
+		req.Header[key] = values
+	}
+	return c.retryPolicy.Do(c.httpClient, req)
 }
 
 // ErrorResponse describes an error response.
@@ -1020,11 +1170,3 @@ type ErrorResponse struct {
 		Param   string `json:"param"`
 	} `json:"error"`
 }
-
-func (c *Client) decodeError(resp *http.Response) error {
-	var errResp ErrorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-		return fmt.Errorf("error decoding error response: %w", err)
-	}
-	return fmt.Errorf("%s: %s", errResp.Error.Type, errResp.Error.Message)
-}
@@ -0,0 +1,66 @@
+package openai
+
+import (
+	"context"
+
+	"github.com/joeychilson/ai/internal/batch"
+)
+
+var defaultBatchLimits = batch.Limits{
+	MaxItems:    2048,
+	MaxTokens:   300000,
+	Concurrency: 4,
+}
+
+// BatchOptions configures how EmbedAll splits and sends the input.
+type BatchOptions struct {
+	// MaxInputs is the maximum number of inputs per request. Defaults to 2048.
+	MaxInputs int
+	// MaxTokens is the maximum number of estimated tokens per request. Defaults to 300000.
+	MaxTokens int
+	// Concurrency is the maximum number of requests sent at the same time. Defaults to 4.
+	Concurrency int
+}
+
+// EmbedAll embeds any number of inputs by splitting them into batches that fit the API limits
+// and sending the batches concurrently. The embeddings are returned in the order of the input,
+// with their Index set to the position in req.Input, and the usage of all batches is combined.
+// Failed requests are retried according to the retry policy of the client.
+func (c *Client) EmbedAll(ctx context.Context, req *EmbedRequest, opts *BatchOptions) (*EmbedResponse, error) {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+	limits := batch.Limits{
+		MaxItems:    opts.MaxInputs,
+		MaxTokens:   opts.MaxTokens,
+		Concurrency: opts.Concurrency,
+	}
+
+	data, responses, err := batch.Embed(ctx, req.Input, limits, defaultBatchLimits,
+		func(e *Embedding) *int { return &e.Index },
+		func(ctx context.Context, input []string) (*EmbedResponse, []Embedding, error) {
+			batchReq := *req
+			batchReq.Input = input
+
+			resp, err := c.Embed(ctx, &batchReq)
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp, resp.Data, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	embedResp := &EmbedResponse{
+		Object: "list",
+		Data:   data,
+		Model:  string(req.Model),
+	}
+	for _, resp := range responses {
+		embedResp.Model = resp.Model
+		embedResp.Usage.PromptTokens += resp.Usage.PromptTokens
+		embedResp.Usage.TotalTokens += resp.Usage.TotalTokens
+	}
+	return embedResp, nil
+}
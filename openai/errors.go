@@ -0,0 +1,87 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// APIError is an error returned by the OpenAI API.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+	Param      string
+	RequestID  string
+	Body       []byte
+}
+
+// Error returns the error message.
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("openai: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), truncate(string(e.Body), 200))
+	}
+	return fmt.Sprintf("openai: %d %s: %s", e.StatusCode, e.Type, e.Message)
+}
+
+// IsRateLimited reports whether err is an API error caused by rate limiting.
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// IsOverloaded reports whether err is an API error caused by the API being overloaded.
+func IsOverloaded(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusServiceUnavailable
+}
+
+// IsAuth reports whether err is an API error caused by invalid credentials or missing permissions.
+func IsAuth(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// IsNotFound reports whether err is an API error caused by a missing resource.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsContextLength reports whether err is an API error caused by a prompt exceeding the context window.
+func IsContextLength(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == "context_length_exceeded"
+}
+
+func (c *Client) decodeError(resp *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("error reading error response: %w", err)
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("x-request-id"),
+		Body:       body,
+	}
+
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		apiErr.Type = errResp.Error.Type
+		apiErr.Code = errResp.Error.Code
+		apiErr.Message = errResp.Error.Message
+		apiErr.Param = errResp.Error.Param
+	}
+	return apiErr
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
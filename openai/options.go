@@ -0,0 +1,65 @@
+package openai
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/joeychilson/ai/retry"
+)
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL sets the base URL used for API requests.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API requests. A nil client is ignored.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
+}
+
+// WithHeader sets a header that is sent with every request, overriding any default value.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(key, value)
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTimeout sets the timeout of the HTTP client used for API requests.
+// The configured HTTP client is copied, so it is never modified.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry failed requests.
+func WithRetryPolicy(policy retry.Policy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithMaxRetries sets the maximum number of retries of the current retry policy.
+// A value of 0 disables retries.
+func WithMaxRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.retryPolicy.MaxRetries = maxRetries
+	}
+}
@@ -0,0 +1,144 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/joeychilson/ai/internal/sse"
+)
+
+// Stream is a stream of chunks from a streamed chat completion.
+//
+// Chunks are read one at a time in the style of bufio.Scanner:
+//
+//	stream, err := client.NewChatStream(ctx, req)
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//
+//	for stream.Next() {
+//		chunk := stream.Current()
+//		// ...
+//	}
+//	if err := stream.Err(); err != nil {
+//		return err
+//	}
+type Stream struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	body    io.ReadCloser
+	reader  *sse.Reader
+	current *ChatChunk
+	err     error
+	done    bool
+}
+
+// NewChatStream performs a streamed chat completion request and returns the stream of chunks.
+// The stream must be closed when it is no longer used.
+func (c *Client) NewChatStream(ctx context.Context, req *ChatRequest) (*Stream, error) {
+	req.Stream = true
+
+	url := fmt.Sprintf("%s/chat/completions", c.baseURL)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	resp, err := c.requestJSON(ctx, url, req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to perform request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer cancel()
+		defer resp.Body.Close()
+		return nil, c.decodeError(resp)
+	}
+
+	return &Stream{
+		ctx:    ctx,
+		cancel: cancel,
+		body:   resp.Body,
+		reader: sse.NewReader(resp.Body),
+	}, nil
+}
+
+// Next advances the stream to the next chunk, which is then available through Current.
+// It returns false when the stream ends or an error occurs, in which case Err returns the error.
+func (s *Stream) Next() bool {
+	if s.done {
+		return false
+	}
+
+	event, err := s.reader.Next()
+	if err != nil {
+		s.done = true
+		if err != io.EOF {
+			s.err = fmt.Errorf("failed to read response: %w", err)
+		}
+		return false
+	}
+
+	if string(event.Data) == "[DONE]" {
+		s.done = true
+		return false
+	}
+
+	var chunk ChatChunk
+	if err := json.Unmarshal(event.Data, &chunk); err != nil {
+		s.done = true
+		s.err = fmt.Errorf("failed to unmarshal event: %w", err)
+		return false
+	}
+	s.current = &chunk
+	return true
+}
+
+// Current returns the most recent chunk read by Next.
+func (s *Stream) Current() *ChatChunk {
+	return s.current
+}
+
+// Err returns the first error that occurred while reading the stream.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// Close stops the stream and closes the underlying response body.
+func (s *Stream) Close() error {
+	s.cancel()
+	return s.body.Close()
+}
+
+// Chunks returns a channel that receives the chunks of the stream.
+// The channel is closed when the stream ends or is closed, after which Err reports any error.
+func (s *Stream) Chunks() <-chan *ChatChunk {
+	chunks := make(chan *ChatChunk)
+	go func() {
+		defer close(chunks)
+		for s.Next() {
+			select {
+			case chunks <- s.Current():
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+	return chunks
+}
+
+// ForEach calls fn for each chunk of the stream and closes the stream when done.
+// If fn returns an error, the stream is stopped and the error is returned.
+func (s *Stream) ForEach(fn func(chunk *ChatChunk) error) error {
+	defer s.Close()
+
+	for s.Next() {
+		if err := fn(s.Current()); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
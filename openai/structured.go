@@ -0,0 +1,99 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/joeychilson/ai/schema"
+)
+
+// RefusalError is returned by ChatStructured when the model refuses to answer.
+type RefusalError struct {
+	Refusal string
+}
+
+// Error returns the error message.
+func (e *RefusalError) Error() string {
+	return fmt.Sprintf("openai: model refused to answer: %s", e.Refusal)
+}
+
+// ParseError is returned by ChatStructured when the model output cannot be decoded.
+type ParseError struct {
+	Content      string
+	FinishReason string
+	Err          error
+}
+
+// Error returns the error message.
+func (e *ParseError) Error() string {
+	if e.FinishReason == "length" {
+		return fmt.Sprintf("openai: failed to parse truncated output: %v", e.Err)
+	}
+	return fmt.Sprintf("openai: failed to parse output: %v", e.Err)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+var invalidSchemaNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// ChatStructured performs a chat completion request whose output must match the JSON schema
+// generated from T, which must be a struct type, and decodes the first choice into a T.
+// The response format of the request is replaced with the strict JSON schema of T.
+//
+// If the model refuses to answer, a *RefusalError is returned. If the output cannot be
+// decoded into a T, a *ParseError is returned. The response is returned in both cases.
+func ChatStructured[T any](ctx context.Context, c *Client, req *ChatRequest) (*T, *ChatResponse, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	s, err := schema.Generate(t)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.Type != "object" || s.Nullable {
+		return nil, nil, fmt.Errorf("openai: structured output type must be a struct, got %s", t)
+	}
+
+	name := invalidSchemaNameChars.ReplaceAllString(t.Name(), "_")
+	if name == "" {
+		name = "response"
+	}
+
+	req.ResponseFormat = &ResponseFormat{
+		Type: ResponseFormatJSONSchema,
+		JSONSchema: &JSONSchema{
+			Name:   name,
+			Schema: s,
+			Strict: true,
+		},
+	}
+
+	resp, err := c.Chat(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, resp, errors.New("openai: response has no choices")
+	}
+
+	choice := resp.Choices[0]
+	if choice.Message.Refusal != "" {
+		return nil, resp, &RefusalError{Refusal: choice.Message.Refusal}
+	}
+
+	var out T
+	if err := json.Unmarshal([]byte(choice.Message.Content), &out); err != nil {
+		return nil, resp, &ParseError{
+			Content:      choice.Message.Content,
+			FinishReason: choice.FinishReason,
+			Err:          err,
+		}
+	}
+	return &out, resp, nil
+}
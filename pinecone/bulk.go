@@ -0,0 +1,198 @@
+package pinecone
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const (
+	defaultBulkMaxBatchBytes   = 2 * 1000 * 1000
+	defaultBulkMaxBatchVectors = 1000
+	defaultBulkConcurrency     = 4
+)
+
+// BulkUpsertOptions configures how BulkUpsert batches and sends the vectors.
+type BulkUpsertOptions struct {
+	// Namespace is the namespace the vectors are upserted to.
+	Namespace string
+	// MaxBatchBytes is the maximum size of the JSON body of a request. Defaults to 2MB, the limit of the API.
+	MaxBatchBytes int
+	// MaxBatchVectors is the maximum number of vectors per request. Defaults to 1000, the limit of the API.
+	MaxBatchVectors int
+	// Concurrency is the maximum number of requests sent at the same time. Defaults to 4.
+	Concurrency int
+	// Progress is called after each batch with the number of vectors upserted and failed so far.
+	// It is never called concurrently.
+	Progress func(upserted, failed int)
+}
+
+// BulkUpsertResponse is the response from BulkUpsert.
+type BulkUpsertResponse struct {
+	UpsertedCount int
+	// FailedIDs are the IDs of the vectors in batches that failed after all retries.
+	FailedIDs []string
+}
+
+// BulkUpsert upserts any number of vectors, split into batches that fit the request limits of the API
+// and sent concurrently. Each request is retried according to the retry policy of the client.
+// Batches that still fail don't stop the upsert; their vector IDs are reported in the response,
+// along with an error wrapping the first failure. The response is returned even when an error is,
+// with the counts of the batches that completed.
+func (c *DataClient) BulkUpsert(ctx context.Context, vectors []Vector, opts *BulkUpsertOptions) (*BulkUpsertResponse, error) {
+	i := 0
+	return c.bulkUpsert(ctx, func() (Vector, bool) {
+		if i >= len(vectors) {
+			return Vector{}, false
+		}
+		i++
+		return vectors[i-1], true
+	}, opts)
+}
+
+// BulkUpsertChan is like BulkUpsert, but reads the vectors from a channel until it is closed,
+// so the vectors don't have to be held in memory at once.
+func (c *DataClient) BulkUpsertChan(ctx context.Context, vectors <-chan Vector, opts *BulkUpsertOptions) (*BulkUpsertResponse, error) {
+	return c.bulkUpsert(ctx, func() (Vector, bool) {
+		select {
+		case vector, ok := <-vectors:
+			return vector, ok
+		case <-ctx.Done():
+			return Vector{}, false
+		}
+	}, opts)
+}
+
+// upsertBatch is an upsert request of vectors that are already encoded.
+type upsertBatch struct {
+	Vectors   []json.RawMessage `json:"vectors"`
+	Namespace string            `json:"namespace,omitempty"`
+
+	ids []string
+}
+
+func (c *DataClient) bulkUpsert(ctx context.Context, next func() (Vector, bool), opts *BulkUpsertOptions) (*BulkUpsertResponse, error) {
+	if opts == nil {
+		opts = &BulkUpsertOptions{}
+	}
+	maxBytes := opts.MaxBatchBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultBulkMaxBatchBytes
+	}
+	maxVectors := opts.MaxBatchVectors
+	if maxVectors <= 0 {
+		maxVectors = defaultBulkMaxBatchVectors
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBulkConcurrency
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	bulkResp := &BulkUpsertResponse{}
+	batches := make(chan *upsertBatch)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				upserted, err := c.upsertBatch(ctx, b)
+
+				mu.Lock()
+				if err != nil {
+					bulkResp.FailedIDs = append(bulkResp.FailedIDs, b.ids...)
+					if firstErr == nil {
+						firstErr = err
+					}
+				} else {
+					bulkResp.UpsertedCount += upserted
+				}
+				if opts.Progress != nil {
+					opts.Progress(bulkResp.UpsertedCount, len(bulkResp.FailedIDs))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// The size of the request body without any vectors.
+	overhead := len(`{"vectors":[],"namespace":""}`) + len(opts.Namespace)
+
+	var encodeErr error
+	current := &upsertBatch{Namespace: opts.Namespace}
+	size := overhead
+	send := func() bool {
+		if len(current.Vectors) == 0 {
+			return true
+		}
+		select {
+		case batches <- current:
+		case <-ctx.Done():
+			return false
+		}
+		current = &upsertBatch{Namespace: opts.Namespace}
+		size = overhead
+		return true
+	}
+
+	for {
+		vector, ok := next()
+		if !ok {
+			break
+		}
+		encoded, err := json.Marshal(vector)
+		if err != nil {
+			encodeErr = fmt.Errorf("failed to encode vector %s: %w", vector.ID, err)
+			break
+		}
+		if len(current.Vectors) >= maxVectors || size+len(encoded)+1 > maxBytes {
+			if !send() {
+				break
+			}
+		}
+		current.Vectors = append(current.Vectors, encoded)
+		current.ids = append(current.ids, vector.ID)
+		size += len(encoded) + 1
+	}
+	if encodeErr == nil && ctx.Err() == nil {
+		send()
+	}
+	close(batches)
+	wg.Wait()
+
+	switch {
+	case encodeErr != nil:
+		return bulkResp, encodeErr
+	case ctx.Err() != nil:
+		return bulkResp, ctx.Err()
+	case firstErr != nil:
+		return bulkResp, fmt.Errorf("failed to upsert %d vectors: %w", len(bulkResp.FailedIDs), firstErr)
+	}
+	return bulkResp, nil
+}
+
+func (c *DataClient) upsertBatch(ctx context.Context, b *upsertBatch) (int, error) {
+	resp, err := c.request(ctx, "POST", "/vectors/upsert", b)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, decodeError(resp)
+	}
+
+	var upserted UpsertVectorsResponse
+	err = json.NewDecoder(resp.Body).Decode(&upserted)
+	if err != nil {
+		return 0, err
+	}
+	return upserted.UpsertedCount, nil
+}
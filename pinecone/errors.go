@@ -0,0 +1,92 @@
+package pinecone
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// APIError is an error returned by the Pinecone API.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Code       string
+	Message    string
+	Param      string
+	RequestID  string
+	Body       []byte
+}
+
+// Error returns the error message.
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("pinecone: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), truncate(string(e.Body), 200))
+	}
+	if e.Code == "" {
+		return fmt.Sprintf("pinecone: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+	}
+	return fmt.Sprintf("pinecone: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// IsRateLimited reports whether err is an API error caused by rate limiting.
+func IsRateLimited(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// IsOverloaded reports whether err is an API error caused by the API being overloaded.
+func IsOverloaded(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusServiceUnavailable
+}
+
+// IsAuth reports whether err is an API error caused by invalid credentials or missing permissions.
+func IsAuth(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// IsNotFound reports whether err is an API error caused by a missing resource.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+func decodeError(resp *http.Response) error {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("error reading error response: %w", err)
+	}
+
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("x-pinecone-request-id"),
+		Body:       body,
+	}
+
+	// The control API nests the error in an object, while the data API returns
+	// the message at the top level.
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Message != "" {
+		apiErr.Code = errResp.Error.Code
+		apiErr.Message = errResp.Error.Message
+		return apiErr
+	}
+
+	var dataErrResp struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &dataErrResp); err == nil {
+		apiErr.Message = dataErrResp.Message
+	}
+	return apiErr
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,344 @@
+package pinecone
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Filter is a metadata filter, in the JSON format of the Pinecone API.
+// Filters are built with Eq, Ne, Gt, Gte, Lt, Lte, In, Nin, Exists, And and Or.
+type Filter map[string]any
+
+// Eq matches vectors whose metadata field is equal to value, or contains it for a list of strings.
+func Eq(field string, value any) Filter {
+	return Filter{field: map[string]any{"$eq": value}}
+}
+
+// Ne matches vectors whose metadata field is not equal to value.
+func Ne(field string, value any) Filter {
+	return Filter{field: map[string]any{"$ne": value}}
+}
+
+// Gt matches vectors whose metadata field is a number greater than value.
+func Gt(field string, value any) Filter {
+	return Filter{field: map[string]any{"$gt": value}}
+}
+
+// Gte matches vectors whose metadata field is a number greater than or equal to value.
+func Gte(field string, value any) Filter {
+	return Filter{field: map[string]any{"$gte": value}}
+}
+
+// Lt matches vectors whose metadata field is a number less than value.
+func Lt(field string, value any) Filter {
+	return Filter{field: map[string]any{"$lt": value}}
+}
+
+// Lte matches vectors whose metadata field is a number less than or equal to value.
+func Lte(field string, value any) Filter {
+	return Filter{field: map[string]any{"$lte": value}}
+}
+
+// In matches vectors whose metadata field is equal to one of the values.
+func In(field string, values ...any) Filter {
+	return Filter{field: map[string]any{"$in": values}}
+}
+
+// Nin matches vectors whose metadata field is equal to none of the values.
+func Nin(field string, values ...any) Filter {
+	return Filter{field: map[string]any{"$nin": values}}
+}
+
+// Exists matches vectors that have the metadata field if exists is true, or that don't have it otherwise.
+func Exists(field string, exists bool) Filter {
+	return Filter{field: map[string]any{"$exists": exists}}
+}
+
+// And matches vectors that match all of the filters.
+func And(filters ...Filter) Filter {
+	return Filter{"$and": filters}
+}
+
+// Or matches vectors that match any of the filters.
+func Or(filters ...Filter) Filter {
+	return Filter{"$or": filters}
+}
+
+// Validate checks that the filter only uses supported operators with operands of the right type.
+func (f Filter) Validate() error {
+	for key, value := range f {
+		if err := validateFilter(key, value); err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateFilter(key string, value any) error {
+	switch key {
+	case "$and", "$or":
+		filters, ok := filterList(value)
+		if !ok {
+			return fmt.Errorf("%s requires a list of filters", key)
+		}
+		for _, filter := range filters {
+			if len(filter) == 0 {
+				return fmt.Errorf("%s requires non-empty filters", key)
+			}
+			for k, v := range filter {
+				if err := validateFilter(k, v); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if strings.HasPrefix(key, "$") {
+		return fmt.Errorf("unsupported operator %s", key)
+	}
+
+	operators, ok := operatorMap(value)
+	if !ok {
+		// A field compared to a value is a shorthand for $eq.
+		if !isScalar(value) {
+			return fmt.Errorf("field %s must be compared to a string, number or boolean", key)
+		}
+		return nil
+	}
+	if len(operators) == 0 {
+		return fmt.Errorf("field %s has no operators", key)
+	}
+	for op, operand := range operators {
+		switch op {
+		case "$eq", "$ne":
+			if !isScalar(operand) {
+				return fmt.Errorf("%s on field %s requires a string, number or boolean", op, key)
+			}
+		case "$gt", "$gte", "$lt", "$lte":
+			if _, ok := toNumber(operand); !ok {
+				return fmt.Errorf("%s on field %s requires a number", op, key)
+			}
+		case "$in", "$nin":
+			values, ok := valueList(operand)
+			if !ok {
+				return fmt.Errorf("%s on field %s requires a list", op, key)
+			}
+			for _, v := range values {
+				if _, isString := v.(string); !isString {
+					if _, isNumber := toNumber(v); !isNumber {
+						return fmt.Errorf("%s on field %s requires strings or numbers", op, key)
+					}
+				}
+			}
+		case "$exists":
+			if _, ok := operand.(bool); !ok {
+				return fmt.Errorf("$exists on field %s requires a boolean", key)
+			}
+		default:
+			return fmt.Errorf("unsupported operator %s on field %s", op, key)
+		}
+	}
+	return nil
+}
+
+// Match reports whether the metadata matches the filter, evaluated the way Pinecone does.
+// It can be used to filter vectors held in memory. An empty filter matches any metadata,
+// and invalid parts of a filter never match.
+func (f Filter) Match(metadata map[string]any) bool {
+	for key, value := range f {
+		if !matchFilter(key, value, metadata) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchFilter(key string, value any, metadata map[string]any) bool {
+	switch key {
+	case "$and", "$or":
+		filters, ok := filterList(value)
+		if !ok {
+			return false
+		}
+		for _, filter := range filters {
+			matched := filter.Match(metadata)
+			if key == "$and" && !matched {
+				return false
+			}
+			if key == "$or" && matched {
+				return true
+			}
+		}
+		return key == "$and"
+	}
+
+	field, exists := metadata[key]
+	operators, ok := operatorMap(value)
+	if !ok {
+		return exists && containsValue(field, value)
+	}
+	for op, operand := range operators {
+		if !matchOperator(op, operand, field, exists) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchOperator(op string, operand, field any, exists bool) bool {
+	switch op {
+	case "$eq":
+		return exists && containsValue(field, operand)
+	case "$ne":
+		return !exists || !containsValue(field, operand)
+	case "$gt", "$gte", "$lt", "$lte":
+		if !exists {
+			return false
+		}
+		a, ok := toNumber(field)
+		if !ok {
+			return false
+		}
+		b, ok := toNumber(operand)
+		if !ok {
+			return false
+		}
+		switch op {
+		case "$gt":
+			return a > b
+		case "$gte":
+			return a >= b
+		case "$lt":
+			return a < b
+		default:
+			return a <= b
+		}
+	case "$in", "$nin":
+		values, ok := valueList(operand)
+		if !ok {
+			return false
+		}
+		found := false
+		if exists {
+			for _, v := range values {
+				if containsValue(field, v) {
+					found = true
+					break
+				}
+			}
+		}
+		return found == (op == "$in")
+	case "$exists":
+		want, ok := operand.(bool)
+		return ok && exists == want
+	default:
+		return false
+	}
+}
+
+// containsValue reports whether the metadata field equals value, or contains it for a list.
+func containsValue(field, value any) bool {
+	if values, ok := valueList(field); ok {
+		for _, v := range values {
+			if equalValues(v, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return equalValues(field, value)
+}
+
+func equalValues(a, b any) bool {
+	if x, ok := toNumber(a); ok {
+		y, ok := toNumber(b)
+		return ok && x == y
+	}
+	switch a := a.(type) {
+	case string:
+		s, ok := b.(string)
+		return ok && a == s
+	case bool:
+		v, ok := b.(bool)
+		return ok && a == v
+	}
+	return false
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, bool:
+		return true
+	}
+	_, ok := toNumber(v)
+	return ok
+}
+
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case int, int8, int16, int32, int64:
+		return float64(reflect.ValueOf(n).Int()), true
+	case uint, uint8, uint16, uint32, uint64:
+		return float64(reflect.ValueOf(n).Uint()), true
+	case float32, float64:
+		return reflect.ValueOf(n).Float(), true
+	}
+	return 0, false
+}
+
+// operatorMap returns the operators of a field condition.
+func operatorMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case Filter:
+		return m, true
+	}
+	return nil, false
+}
+
+// filterList returns the filters of an $and or $or condition.
+func filterList(v any) ([]Filter, bool) {
+	switch list := v.(type) {
+	case []Filter:
+		return list, true
+	case []map[string]any:
+		filters := make([]Filter, len(list))
+		for i, m := range list {
+			filters[i] = m
+		}
+		return filters, true
+	case []any:
+		filters := make([]Filter, len(list))
+		for i, item := range list {
+			m, ok := operatorMap(item)
+			if !ok {
+				return nil, false
+			}
+			filters[i] = m
+		}
+		return filters, true
+	}
+	return nil, false
+}
+
+// valueList returns the values of a list, such as the operand of $in or a list metadata field.
+func valueList(v any) ([]any, bool) {
+	if list, ok := v.([]any); ok {
+		return list, true
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	list := make([]any, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
@@ -0,0 +1,118 @@
+package pinecone
+
+import (
+	"context"
+	"fmt"
+)
+
+// VectorIDIterator iterates over the pages of vector IDs of a namespace of a serverless index.
+//
+//	it := client.NewVectorIDIterator(ctx, &pinecone.ListVectorIDsRequest{Prefix: "doc1#"})
+//	for it.Next() {
+//		ids := it.IDs()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type VectorIDIterator struct {
+	client *DataClient
+	ctx    context.Context
+	req    ListVectorIDsRequest
+	ids    []string
+	done   bool
+	err    error
+}
+
+// NewVectorIDIterator creates an iterator over the vector IDs matching the namespace and prefix of the request,
+// starting at its pagination token. The limit of the request sets the page size.
+func (c *DataClient) NewVectorIDIterator(ctx context.Context, req *ListVectorIDsRequest) *VectorIDIterator {
+	return &VectorIDIterator{
+		client: c,
+		ctx:    ctx,
+		req:    *req,
+	}
+}
+
+// Next fetches the next page of IDs. It returns false when there are no more pages or an error occurred.
+func (it *VectorIDIterator) Next() bool {
+	for !it.done && it.err == nil {
+		resp, err := it.client.ListVectorIDs(it.ctx, &it.req)
+		if err != nil {
+			it.err = err
+			break
+		}
+
+		it.req.PaginationToken = resp.Pagination.Next
+		it.done = resp.Pagination.Next == ""
+
+		it.ids = make([]string, len(resp.Vectors))
+		for i, vector := range resp.Vectors {
+			it.ids[i] = vector.ID
+		}
+		if len(it.ids) > 0 {
+			return true
+		}
+	}
+	it.ids = nil
+	return false
+}
+
+// IDs returns the IDs of the current page.
+func (it *VectorIDIterator) IDs() []string {
+	return it.ids
+}
+
+// PaginationToken returns the token of the page after the current one, which can be used to resume the iteration.
+func (it *VectorIDIterator) PaginationToken() string {
+	return it.req.PaginationToken
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *VectorIDIterator) Err() error {
+	return it.err
+}
+
+// FetchVectorPages walks every page of vector IDs matching the namespace and prefix of the request,
+// and calls fn with the vectors of each page. Returning an error from fn stops the walk.
+func (c *DataClient) FetchVectorPages(ctx context.Context, req *ListVectorIDsRequest, fn func(*FetchVectorsResponse) error) error {
+	it := c.NewVectorIDIterator(ctx, req)
+	for it.Next() {
+		fetchResp, err := c.FetchVectors(ctx, &FetchVectorsRequest{
+			IDs:       it.IDs(),
+			Namespace: req.Namespace,
+		})
+		if err != nil {
+			return err
+		}
+		if err := fn(fetchResp); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// DeleteVectorsByPrefix deletes every vector whose ID starts with the prefix in the namespace,
+// such as all chunks of a document, and returns the number of deleted vectors.
+func (c *DataClient) DeleteVectorsByPrefix(ctx context.Context, namespace, prefix string) (int, error) {
+	if prefix == "" {
+		return 0, fmt.Errorf("prefix is required")
+	}
+
+	deleted := 0
+	it := c.NewVectorIDIterator(ctx, &ListVectorIDsRequest{
+		Namespace: namespace,
+		Prefix:    prefix,
+	})
+	for it.Next() {
+		err := c.DeleteVectors(ctx, &DeleteVectorsRequest{
+			IDs:       it.IDs(),
+			Namespace: namespace,
+		})
+		if err != nil {
+			return deleted, err
+		}
+		deleted += len(it.IDs())
+	}
+	return deleted, it.Err()
+}
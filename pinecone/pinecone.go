@@ -6,28 +6,104 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
+
+	"github.com/joeychilson/ai/internal/batch"
+	"github.com/joeychilson/ai/retry"
 )
 
 const (
 	defaultBaseURL = "https://api.pinecone.io"
 )
 
+// client holds the configuration shared by the control and data clients.
+type client struct {
+	baseURL      string
+	token        string
+	httpClient   *http.Client
+	headers      http.Header
+	userAgent    string
+	timeout      time.Duration
+	retryPolicy  retry.Policy
+	retryDeletes bool
+}
+
+func newClient(baseURL, token string, opts []Option) client {
+	c := client{
+		baseURL:     baseURL,
+		token:       token,
+		httpClient:  http.DefaultClient,
+		headers:     make(http.Header),
+		retryPolicy: retry.DefaultPolicy(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.timeout > 0 {
+		httpClient := *c.httpClient
+		httpClient.Timeout = c.timeout
+		c.httpClient = &httpClient
+	}
+	return c
+}
+
+func (c *client) request(ctx context.Context, method string, path string, body any) (*http.Response, error) {
+	return c.send(ctx, method, path, body, c.retryPolicy)
+}
+
+// requestDelete is like request, but deletes are only retried when enabled with
+// WithRetryDeletes since a retried delete may observe the effects of the first attempt.
+func (c *client) requestDelete(ctx context.Context, method string, path string, body any) (*http.Response, error) {
+	policy := retry.NoRetry
+	if c.retryDeletes {
+		policy = c.retryPolicy
+	}
+	return c.send(ctx, method, path, body, policy)
+}
+
+func (c *client) send(ctx context.Context, method string, path string, body any, policy retry.Policy) (*http.Response, error) {
+	url := c.baseURL + path
+
+	var buf io.ReadWriter
+	if body != nil {
+		buf = &bytes.Buffer{}
+		err := json.NewEncoder(buf).Encode(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq.Header.Set("Api-Key", c.token)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if c.userAgent != "" {
+		httpReq.Header.Set("User-Agent", c.userAgent)
+	}
+	for key, values := range c.headers {
+		httpReq.Header[key] = values
+	}
+	return policy.Do(c.httpClient, httpReq)
+}
+
 // ControlClient is a client for the Pinecone control API.
 type ControlClient struct {
-	baseURL    string
-	token      string
-	httpClient *http.Client
+	client
 }
 
-// NewControlClient creates a new Client for the control API using the given token.
-func NewControlClient(token string) *ControlClient {
+// NewControlClient creates a new Client for the control API using the given token and options.
+func NewControlClient(token string, opts ...Option) *ControlClient {
 	return &ControlClient{
-		baseURL:    defaultBaseURL,
-		token:      token,
-		httpClient: http.DefaultClient,
+		client: newClient(defaultBaseURL, token, opts),
 	}
 }
 
@@ -209,7 +285,7 @@ func (c *ControlClient) DescribeIndex(ctx context.Context, indexName string) (*D
 
 // DeleteIndex deletes an index by name.
 func (c *ControlClient) DeleteIndex(ctx context.Context, indexName string) error {
-	resp, err := c.request(ctx, "DELETE", "/indexes/"+url.PathEscape(indexName), nil)
+	resp, err := c.requestDelete(ctx, "DELETE", "/indexes/"+url.PathEscape(indexName), nil)
 	if err != nil {
 		return err
 	}
@@ -368,7 +444,7 @@ func (c *ControlClient) DescribeCollection(ctx context.Context, collectionName s
 
 // DeleteCollection deletes a collection by name.
 func (c *ControlClient) DeleteCollection(ctx context.Context, collectionName string) error {
-	resp, err := c.request(ctx, "DELETE", "/collections/"+url.PathEscape(collectionName), nil)
+	resp, err := c.requestDelete(ctx, "DELETE", "/collections/"+url.PathEscape(collectionName), nil)
 	if err != nil {
 		return err
 	}
@@ -380,51 +456,22 @@ func (c *ControlClient) DeleteCollection(ctx context.Context, collectionName str
 	return nil
 }
 
-func (c *ControlClient) request(ctx context.Context, method string, path string, body any) (*http.Response, error) {
-	url := c.baseURL + path
-
-	var buf io.ReadWriter
-	if body != nil {
-		buf = &bytes.Buffer{}
-		err := json.NewEncoder(buf).Encode(body)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, method, url, buf)
-	if err != nil {
-		return nil, err
-	}
-
-	httpReq.Header.Set("Api-Key", c.token)
-	if body != nil {
-		httpReq.Header.Set("Content-Type", "application/json")
-	}
-	return c.httpClient.Do(httpReq)
-}
-
 // DataClient is a client for the Pinecone data API.
 type DataClient struct {
-	baseURL    string
-	token      string
-	httpClient *http.Client
+	client
 }
 
-// NewDataClient creates a new DataClient for the data API using the given index host and token.
-func NewDataClient(indexHost, token string) *DataClient {
+// NewDataClient creates a new DataClient for the data API using the given index host, token and options.
+func NewDataClient(indexHost, token string, opts ...Option) *DataClient {
 	return &DataClient{
-		baseURL:    indexHost,
-		token:      token,
-		httpClient: http.DefaultClient,
+		client: newClient(indexHost, token, opts),
 	}
 }
 
 // SparseValue is a sparse vector.
-type SparseValue struct {
-	Indices []int     `json:"indices"`
-	Values  []float32 `json:"values"`
-}
+//
+// Deprecated: Use SparseVector.
+type SparseValue = SparseVector
 
 // Usage represents the usage of the index.
 type Usage struct {
@@ -435,7 +482,7 @@ type Usage struct {
 type Vector struct {
 	ID           string         `json:"id"`
 	Values       []float32      `json:"values"`
-	SparseValues []SparseValue  `json:"sparseValues,omitempty"`
+	SparseValues *SparseVector  `json:"sparseValues,omitempty"`
 	Metadata     map[string]any `json:"metadata,omitempty"`
 }
 
@@ -472,14 +519,14 @@ func (c *DataClient) UpsertVectors(ctx context.Context, req *UpsertVectorsReques
 
 // QueryVectorsRequest is the request to query vectors.
 type QueryVectorsRequest struct {
-	Vector          []float32      `json:"vector"`
-	TopK            int            `json:"topK"`
-	ID              string         `json:"id,omitempty"`
-	Namespace       string         `json:"namespace,omitempty"`
-	Filter          map[string]any `json:"filter,omitempty"`
-	IncludeValues   bool           `json:"includeValues,omitempty"`
-	IncludeMetadata bool           `json:"includeMetadata,omitempty"`
-	SparseVector    *SparseVector  `json:"sparseVector,omitempty"`
+	Vector          []float32     `json:"vector,omitempty"`
+	TopK            int           `json:"topK"`
+	ID              string        `json:"id,omitempty"`
+	Namespace       string        `json:"namespace,omitempty"`
+	Filter          Filter        `json:"filter,omitempty"`
+	IncludeValues   bool          `json:"includeValues,omitempty"`
+	IncludeMetadata bool          `json:"includeMetadata,omitempty"`
+	SparseVector    *SparseVector `json:"sparseVector,omitempty"`
 }
 
 // Validate checks if the request is valid.
@@ -487,7 +534,18 @@ func (r *QueryVectorsRequest) Validate() error {
 	if r.TopK <= 0 {
 		return fmt.Errorf("top k must be greater than 0")
 	}
-	return nil
+	if r.ID != "" && (len(r.Vector) > 0 || r.SparseVector != nil) {
+		return fmt.Errorf("id cannot be combined with vector or sparse vector")
+	}
+	if r.ID == "" && len(r.Vector) == 0 && r.SparseVector == nil {
+		return fmt.Errorf("id, vector or sparse vector is required")
+	}
+	if r.SparseVector != nil {
+		if err := r.SparseVector.Validate(); err != nil {
+			return err
+		}
+	}
+	return r.Filter.Validate()
 }
 
 // SparseVector is a sparse vector.
@@ -496,6 +554,22 @@ type SparseVector struct {
 	Values  []float32 `json:"values"`
 }
 
+// Validate checks if the sparse vector is valid.
+func (v *SparseVector) Validate() error {
+	if len(v.Indices) != len(v.Values) {
+		return fmt.Errorf("sparse vector has %d indices but %d values", len(v.Indices), len(v.Values))
+	}
+	if len(v.Indices) == 0 {
+		return fmt.Errorf("sparse vector must have at least one value")
+	}
+	for _, index := range v.Indices {
+		if index < 0 || int64(index) > math.MaxUint32 {
+			return fmt.Errorf("sparse vector index %d is out of range", index)
+		}
+	}
+	return nil
+}
+
 // QueryVectorsResponse is the response from the QueryVectors API.
 type QueryVectorsResponse struct {
 	Namespace string  `json:"namespace"`
@@ -505,11 +579,11 @@ type QueryVectorsResponse struct {
 
 // Match represents a matching vector.
 type Match struct {
-	ID            string         `json:"id"`
-	Score         float32        `json:"score,omitempty"`
-	Values        []float32      `json:"values,omitempty"`
-	SpareseValues []SparseValue  `json:"sparseValues,omitempty"`
-	Metadata      map[string]any `json:"metadata,omitempty"`
+	ID           string         `json:"id"`
+	Score        float32        `json:"score,omitempty"`
+	Values       []float32      `json:"values,omitempty"`
+	SparseValues *SparseVector  `json:"sparseValues,omitempty"`
+	Metadata     map[string]any `json:"metadata,omitempty"`
 }
 
 // QueryVectors queries the index for vectors.
@@ -536,6 +610,16 @@ func (c *DataClient) QueryVectors(ctx context.Context, req *QueryVectorsRequest)
 	return &queryResp, nil
 }
 
+const (
+	// fetchMaxIDs is the maximum number of IDs fetched per request.
+	fetchMaxIDs = 1000
+	// fetchMaxQueryLength is the maximum length of the encoded IDs per request, which keeps
+	// the URL well below the limits of the proxies in front of the API.
+	fetchMaxQueryLength = 4000
+	// fetchConcurrency is the maximum number of fetch requests sent at the same time.
+	fetchConcurrency = 4
+)
+
 // FetchVectorsRequest is the request to fetch vectors.
 type FetchVectorsRequest struct {
 	IDs       []string `json:"ids"`
@@ -544,18 +628,67 @@ type FetchVectorsRequest struct {
 
 // FetchVectorsResponse is the response from the FetchVectors API.
 type FetchVectorsResponse struct {
-	Vectors map[string]struct {
-		ID       string         `json:"id"`
-		Values   []float32      `json:"values"`
-		Metadata map[string]any `json:"metadata,omitempty"`
-	} `json:"vectors"`
-	Namespace string `json:"namespace,omitempty"`
-	Usage     Usage  `json:"usage,omitempty"`
+	Vectors   map[string]Vector `json:"vectors"`
+	Namespace string            `json:"namespace,omitempty"`
+	Usage     Usage             `json:"usage,omitempty"`
+	// MissingIDs are the requested IDs that were not found, in the order they were requested.
+	MissingIDs []string `json:"-"`
 }
 
-// FetchVectors fetches vectors from the index.
+// FetchVectors fetches vectors by ID from the index. Large ID lists are split into several
+// requests that are sent concurrently and merged into a single response.
 func (c *DataClient) FetchVectors(ctx context.Context, req *FetchVectorsRequest) (*FetchVectorsResponse, error) {
-	resp, err := c.request(ctx, "GET", "/vectors/fetch", req)
+	ranges := batch.Split(len(req.IDs), fetchMaxIDs, fetchMaxQueryLength, func(i int) int {
+		return len("&ids=") + len(url.QueryEscape(req.IDs[i]))
+	})
+	responses := make([]*FetchVectorsResponse, len(ranges))
+
+	err := batch.Run(ctx, len(ranges), batch.Options{Concurrency: fetchConcurrency}, func(ctx context.Context, i int) error {
+		resp, err := c.fetchVectors(ctx, req.IDs[ranges[i][0]:ranges[i][1]], req.Namespace)
+		if err != nil {
+			return err
+		}
+		responses[i] = resp
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fetchResp := &FetchVectorsResponse{
+		Vectors:   make(map[string]Vector, len(req.IDs)),
+		Namespace: req.Namespace,
+	}
+	for _, resp := range responses {
+		for id, vector := range resp.Vectors {
+			fetchResp.Vectors[id] = vector
+		}
+		if resp.Namespace != "" {
+			fetchResp.Namespace = resp.Namespace
+		}
+		fetchResp.Usage.ReadUnits += resp.Usage.ReadUnits
+	}
+
+	seen := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if _, ok := fetchResp.Vectors[id]; !ok && !seen[id] {
+			fetchResp.MissingIDs = append(fetchResp.MissingIDs, id)
+		}
+		seen[id] = true
+	}
+	return fetchResp, nil
+}
+
+func (c *DataClient) fetchVectors(ctx context.Context, ids []string, namespace string) (*FetchVectorsResponse, error) {
+	query := make(url.Values)
+	query["ids"] = ids
+	if namespace != "" {
+		query.Set("namespace", namespace)
+	}
+
+	url := fmt.Sprintf("/vectors/fetch?%s", query.Encode())
+
+	resp, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -577,7 +710,7 @@ func (c *DataClient) FetchVectors(ctx context.Context, req *FetchVectorsRequest)
 type UpdateVectorRequest struct {
 	ID           string         `json:"id"`
 	Values       []float32      `json:"values,omitempty"`
-	SparseValues []SparseValue  `json:"sparseValues,omitempty"`
+	SparseValues *SparseVector  `json:"sparseValues,omitempty"`
 	Metadata     map[string]any `json:"setMetadata,omitempty"`
 	Namespace    string         `json:"namespace,omitempty"`
 }
@@ -598,15 +731,19 @@ func (c *DataClient) UpdateVector(ctx context.Context, req *UpdateVectorRequest)
 
 // DeleteVectorsRequest is the request to delete vectors.
 type DeleteVectorsRequest struct {
-	IDs       []string       `json:"ids"`
-	DeleteAll *bool          `json:"deleteAll,omitempty"`
-	Namespace string         `json:"namespace,omitempty"`
-	Filter    map[string]any `json:"filter,omitempty"`
+	IDs       []string `json:"ids"`
+	DeleteAll *bool    `json:"deleteAll,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+	Filter    Filter   `json:"filter,omitempty"`
 }
 
 // DeleteVectors deletes vectors from the index.
 func (c *DataClient) DeleteVectors(ctx context.Context, req *DeleteVectorsRequest) error {
-	resp, err := c.request(ctx, "POST", "/vectors/delete", req)
+	if err := req.Filter.Validate(); err != nil {
+		return err
+	}
+
+	resp, err := c.requestDelete(ctx, "POST", "/vectors/delete", req)
 	if err != nil {
 		return err
 	}
@@ -676,7 +813,7 @@ func (c *DataClient) ListVectorIDs(ctx context.Context, req *ListVectorIDsReques
 
 // IndexStatsRequest is the request to get index stats.
 type IndexStatsRequest struct {
-	Filter map[string]any `json:"filter,omitempty"`
+	Filter Filter `json:"filter,omitempty"`
 }
 
 // IndexStatsResponse is the response from the IndexStats API.
@@ -694,6 +831,10 @@ type NamespaceSummary struct {
 
 // IndexStats gets statistics about the index.
 func (c *DataClient) IndexStats(ctx context.Context, req *IndexStatsRequest) (*IndexStatsResponse, error) {
+	if err := req.Filter.Validate(); err != nil {
+		return nil, err
+	}
+
 	resp, err := c.request(ctx, "POST", "/describe_index_stats", req)
 	if err != nil {
 		return nil, err
@@ -713,30 +854,6 @@ func (c *DataClient) IndexStats(ctx context.Context, req *IndexStatsRequest) (*I
 	return &statsResp, nil
 }
 
-func (c *DataClient) request(ctx context.Context, method string, path string, body any) (*http.Response, error) {
-	url := c.baseURL + path
-
-	var buf io.ReadWriter
-	if body != nil {
-		buf = &bytes.Buffer{}
-		err := json.NewEncoder(buf).Encode(body)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, method, url, buf)
-	if err != nil {
-		return nil, err
-	}
-
-	httpReq.Header.Set("Api-Key", c.token)
-	if body != nil {
-		httpReq.Header.Set("Content-Type", "application/json")
-	}
-	return c.httpClient.Do(httpReq)
-}
-
 // ErrorResponse is an error response.
 type ErrorResponse struct {
 	Status int `json:"status"`
@@ -745,11 +862,3 @@ type ErrorResponse struct {
 		Message string `json:"message"`
 	}
 }
-
-func decodeError(resp *http.Response) error {
-	var errResp ErrorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-		return fmt.Errorf("error decoding error response: %w", err)
-	}
-	return fmt.Errorf("%s: %s", errResp.Error.Code, errResp.Error.Message)
-}
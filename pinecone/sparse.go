@@ -0,0 +1,195 @@
+package pinecone
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// HybridScale weights a dense and a sparse query vector for a hybrid query, where alpha
+// between 0 and 1 is the weight of the dense vector and 1-alpha the weight of the sparse vector.
+// An alpha of 1 is a pure dense query and an alpha of 0 a pure sparse query.
+// The given vectors are not modified.
+func HybridScale(dense []float32, sparse *SparseVector, alpha float32) ([]float32, *SparseVector, error) {
+	if alpha < 0 || alpha > 1 {
+		return nil, nil, fmt.Errorf("alpha must be between 0 and 1")
+	}
+
+	scaledDense := make([]float32, len(dense))
+	for i, v := range dense {
+		scaledDense[i] = v * alpha
+	}
+	if sparse == nil {
+		return scaledDense, nil, nil
+	}
+
+	scaledSparse := &SparseVector{
+		Indices: append([]int(nil), sparse.Indices...),
+		Values:  make([]float32, len(sparse.Values)),
+	}
+	for i, v := range sparse.Values {
+		scaledSparse.Values[i] = v * (1 - alpha)
+	}
+	return scaledDense, scaledSparse, nil
+}
+
+const (
+	defaultBM25K1 = 1.2
+	defaultBM25B  = 0.75
+)
+
+// BM25Encoder encodes text into sparse vectors with BM25 weights, for use in sparse or hybrid queries.
+// Tokens are mapped to sparse indices by hashing, so no vocabulary has to be stored. The indices
+// are 31-bit hashes, so they are the same on every platform, including those with a 32-bit int.
+//
+// The encoder must be fitted on the corpus before documents are encoded. It is safe to encode
+// concurrently, but not while fitting.
+type BM25Encoder struct {
+	// K1 controls the term frequency saturation. Defaults to 1.2 if zero.
+	K1 float64
+	// B controls the document length normalization. Defaults to 0.75 if zero.
+	B float64
+	// Tokenize splits text into tokens. Defaults to Tokenize.
+	Tokenize func(text string) []string
+
+	docFreq   map[int]int
+	numDocs   int
+	avgDocLen float64
+}
+
+// NewBM25Encoder creates a new BM25Encoder with the default parameters.
+func NewBM25Encoder() *BM25Encoder {
+	return &BM25Encoder{
+		K1:       defaultBM25K1,
+		B:        defaultBM25B,
+		Tokenize: Tokenize,
+		docFreq:  make(map[int]int),
+	}
+}
+
+// Tokenize lowercases the text and splits it into runs of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// Fit computes the document frequencies and average document length of the corpus.
+// Fitting again adds the documents to the statistics of the previous fits.
+func (e *BM25Encoder) Fit(documents []string) {
+	if e.docFreq == nil {
+		e.docFreq = make(map[int]int)
+	}
+
+	totalLen := e.avgDocLen * float64(e.numDocs)
+	for _, document := range documents {
+		tf, length := e.termFrequencies(document)
+		for index := range tf {
+			e.docFreq[index]++
+		}
+		totalLen += float64(length)
+		e.numDocs++
+	}
+	if e.numDocs > 0 {
+		e.avgDocLen = totalLen / float64(e.numDocs)
+	}
+}
+
+// EncodeDocument encodes a document to upsert, weighting each token by its saturated and
+// length-normalized term frequency. It returns nil if the document has no tokens.
+func (e *BM25Encoder) EncodeDocument(document string) *SparseVector {
+	tf, length := e.termFrequencies(document)
+
+	avgDocLen := e.avgDocLen
+	if avgDocLen == 0 {
+		avgDocLen = float64(length)
+	}
+
+	k1, b := e.K1, e.B
+	if k1 == 0 {
+		k1 = defaultBM25K1
+	}
+	if b == 0 {
+		b = defaultBM25B
+	}
+
+	weights := make(map[int]float64, len(tf))
+	for index, freq := range tf {
+		f := float64(freq)
+		weights[index] = f * (k1 + 1) / (f + k1*(1-b+b*float64(length)/avgDocLen))
+	}
+	return newSparseVector(weights)
+}
+
+// EncodeDocuments encodes the documents to upsert. The vectors of documents without tokens are nil.
+func (e *BM25Encoder) EncodeDocuments(documents []string) []*SparseVector {
+	vectors := make([]*SparseVector, len(documents))
+	for i, document := range documents {
+		vectors[i] = e.EncodeDocument(document)
+	}
+	return vectors
+}
+
+// EncodeQuery encodes a query, weighting each token by its inverse document frequency in the
+// fitted corpus. The weights are normalized to sum to 1. It returns nil if no token of the query
+// has a positive weight, so a hybrid query falls back to the dense vector alone.
+func (e *BM25Encoder) EncodeQuery(query string) *SparseVector {
+	tf, _ := e.termFrequencies(query)
+
+	weights := make(map[int]float64, len(tf))
+	total := 0.0
+	for index := range tf {
+		df := float64(e.docFreq[index])
+		idf := math.Log((float64(e.numDocs) + 1) / (df + 0.5))
+		if idf <= 0 {
+			continue
+		}
+		weights[index] = idf
+		total += idf
+	}
+	for index := range weights {
+		weights[index] /= total
+	}
+	return newSparseVector(weights)
+}
+
+// termFrequencies returns the frequencies of the hashed tokens and the number of tokens of the text.
+func (e *BM25Encoder) termFrequencies(text string) (map[int]int, int) {
+	tokenize := e.Tokenize
+	if tokenize == nil {
+		tokenize = Tokenize
+	}
+
+	tokens := tokenize(text)
+	tf := make(map[int]int, len(tokens))
+	for _, token := range tokens {
+		h := fnv.New32a()
+		h.Write([]byte(token))
+		// Masking to 31 bits keeps the index positive when int is 32 bits.
+		tf[int(h.Sum32()&math.MaxInt32)]++
+	}
+	return tf, len(tokens)
+}
+
+// newSparseVector creates a sparse vector from the weights, sorted by index, or nil if there are none.
+func newSparseVector(weights map[int]float64) *SparseVector {
+	if len(weights) == 0 {
+		return nil
+	}
+
+	vector := &SparseVector{
+		Indices: make([]int, 0, len(weights)),
+		Values:  make([]float32, 0, len(weights)),
+	}
+	for index := range weights {
+		vector.Indices = append(vector.Indices, index)
+	}
+	sort.Ints(vector.Indices)
+	for _, index := range vector.Indices {
+		vector.Values = append(vector.Values, float32(weights[index]))
+	}
+	return vector
+}
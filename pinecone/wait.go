@@ -0,0 +1,115 @@
+package pinecone
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/joeychilson/ai/retry"
+)
+
+const (
+	IndexStateReady                = "Ready"
+	IndexStateInitializationFailed = "InitializationFailed"
+	CollectionStatusReady          = "Ready"
+)
+
+// pollPolicy is the backoff between two polls of a resource state.
+var pollPolicy = retry.Policy{
+	MinBackoff: time.Second,
+	MaxBackoff: 10 * time.Second,
+}
+
+// poll calls check with backoff until it reports done, returns an error or the context ends.
+func poll(ctx context.Context, check func() (bool, error)) error {
+	for attempt := 1; ; attempt++ {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+
+		timer := time.NewTimer(pollPolicy.Backoff(attempt, nil))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// WaitForIndexReady polls the index until it is ready to serve requests, such as after it was
+// created or configured, and returns its description.
+func (c *ControlClient) WaitForIndexReady(ctx context.Context, indexName string) (*DecribeIndexResponse, error) {
+	var index *DecribeIndexResponse
+	err := poll(ctx, func() (bool, error) {
+		var err error
+		index, err = c.DescribeIndex(ctx, indexName)
+		if err != nil {
+			return false, err
+		}
+		if index.Status.State == IndexStateInitializationFailed {
+			return false, fmt.Errorf("index %s failed to initialize", indexName)
+		}
+		return index.Status.Ready && index.Status.State == IndexStateReady, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// WaitForIndexDeleted polls the index until it no longer exists.
+func (c *ControlClient) WaitForIndexDeleted(ctx context.Context, indexName string) error {
+	return poll(ctx, func() (bool, error) {
+		_, err := c.DescribeIndex(ctx, indexName)
+		if IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+}
+
+// WaitForCollectionReady polls the collection until it is ready and returns its description.
+func (c *ControlClient) WaitForCollectionReady(ctx context.Context, collectionName string) (*DescribeCollectionResponse, error) {
+	var collection *DescribeCollectionResponse
+	err := poll(ctx, func() (bool, error) {
+		var err error
+		collection, err = c.DescribeCollection(ctx, collectionName)
+		if err != nil {
+			return false, err
+		}
+		return collection.Status == CollectionStatusReady, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// CreateIndexAndConnect creates an index, waits until it is ready and returns a DataClient for its host.
+// The DataClient uses the token and options of the ControlClient.
+func (c *ControlClient) CreateIndexAndConnect(ctx context.Context, req *CreateIndexRequest) (*DataClient, error) {
+	if _, err := c.CreateIndex(ctx, req); err != nil {
+		return nil, err
+	}
+
+	index, err := c.WaitForIndexReady(ctx, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if index.Host == "" {
+		return nil, fmt.Errorf("index %s has no host", req.Name)
+	}
+
+	host := index.Host
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+
+	dataClient := &DataClient{client: c.client}
+	dataClient.baseURL = strings.TrimRight(host, "/")
+	dataClient.headers = c.headers.Clone()
+	return dataClient, nil
+}
@@ -0,0 +1,157 @@
+// Package retry implements the retry policy shared by the API clients.
+package retry
+
+import (
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// DefaultStatusCodes are the HTTP status codes retried when a Policy does not specify any.
+// 529 is used by Anthropic to signal that the API is overloaded.
+var DefaultStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	529,
+}
+
+// Policy describes how failed requests are retried.
+type Policy struct {
+	// MaxRetries is the maximum number of retries after the first attempt.
+	MaxRetries int
+	// MinBackoff is the base delay before the first retry.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts, including delays requested by the server.
+	MaxBackoff time.Duration
+	// StatusCodes are the HTTP status codes that are retried. DefaultStatusCodes is used if empty.
+	StatusCodes []int
+}
+
+// DefaultPolicy returns the policy used by the clients unless configured otherwise.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxRetries: 2,
+		MinBackoff: 500 * time.Millisecond,
+		MaxBackoff: 8 * time.Second,
+	}
+}
+
+// NoRetry is a policy that never retries.
+var NoRetry = Policy{}
+
+// Retryable reports whether a response with the given status code should be retried.
+func (p Policy) Retryable(statusCode int) bool {
+	codes := p.StatusCodes
+	if len(codes) == 0 {
+		codes = DefaultStatusCodes
+	}
+	return slices.Contains(codes, statusCode)
+}
+
+// Backoff returns the delay before the given retry attempt, starting at 1.
+// The retry-after-ms and Retry-After headers of resp take precedence when present,
+// otherwise an exponential backoff with jitter is used. Either is capped at MaxBackoff.
+func (p Policy) Backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header); ok {
+			if p.MaxBackoff > 0 {
+				d = min(d, p.MaxBackoff)
+			}
+			return d
+		}
+	}
+
+	backoff := p.MinBackoff
+	if backoff <= 0 {
+		return 0
+	}
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || backoff < p.MaxBackoff); i++ {
+		if backoff > math.MaxInt64/2 {
+			// Without a cap, stop doubling before the duration overflows.
+			backoff = math.MaxInt64
+			break
+		}
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	half := backoff / 2
+	return half + rand.N(half+1)
+}
+
+// Do sends the request with the given client, retrying transport errors and retryable
+// status codes according to the policy. Retries stop as soon as the request context is done.
+// Requests with a body are only retried when the body can be rewound through GetBody.
+func (p Policy) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	canRewind := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		resp, err := client.Do(attemptReq)
+		if attempt >= p.MaxRetries || !canRewind || ctx.Err() != nil {
+			return resp, err
+		}
+		if err == nil && !p.Retryable(resp.StatusCode) {
+			return resp, nil
+		}
+
+		delay := p.Backoff(attempt+1, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func retryAfter(header http.Header) (time.Duration, bool) {
+	if v := header.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms >= 0 {
+			return toDuration(ms * float64(time.Millisecond)), true
+		}
+	}
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+			return toDuration(secs * float64(time.Second)), true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(time.Until(t), 0), true
+		}
+	}
+	return 0, false
+}
+
+// toDuration converts nanoseconds to a duration, saturating instead of overflowing.
+func toDuration(ns float64) time.Duration {
+	if ns >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(ns)
+}
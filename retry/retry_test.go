@@ -0,0 +1,243 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		status int
+		want   bool
+	}{
+		{"default rate limited", Policy{}, http.StatusTooManyRequests, true},
+		{"default server error", Policy{}, http.StatusInternalServerError, true},
+		{"default overloaded", Policy{}, 529, true},
+		{"default bad request", Policy{}, http.StatusBadRequest, false},
+		{"default ok", Policy{}, http.StatusOK, false},
+		{"custom codes", Policy{StatusCodes: []int{http.StatusConflict}}, http.StatusConflict, true},
+		{"custom codes replace defaults", Policy{StatusCodes: []int{http.StatusConflict}}, http.StatusTooManyRequests, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Retryable(tt.status); got != tt.want {
+				t.Errorf("Retryable(%d) = %v, want %v", tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   Policy
+		attempt  int
+		min, max time.Duration
+	}{
+		{"first attempt", Policy{MinBackoff: time.Second, MaxBackoff: time.Minute}, 1, 500 * time.Millisecond, time.Second},
+		{"doubles", Policy{MinBackoff: time.Second, MaxBackoff: time.Minute}, 3, 2 * time.Second, 4 * time.Second},
+		{"capped", Policy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}, 10, 2500 * time.Millisecond, 5 * time.Second},
+		{"no min backoff", Policy{MaxBackoff: time.Second}, 3, 0, 0},
+		{"uncapped large attempt", Policy{MinBackoff: time.Second}, 35, time.Duration(1<<62) / 2, time.Duration(1<<63 - 1)},
+		{"uncapped huge attempt", Policy{MinBackoff: time.Second}, 1000, time.Duration(1<<62) / 2, time.Duration(1<<63 - 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				got := tt.policy.Backoff(tt.attempt, nil)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("Backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoffRetryAfter(t *testing.T) {
+	policy := Policy{MinBackoff: time.Second, MaxBackoff: time.Minute}
+	date := time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat)
+
+	tests := []struct {
+		name     string
+		header   http.Header
+		min, max time.Duration
+	}{
+		{"retry-after-ms", http.Header{"Retry-After-Ms": {"250"}}, 250 * time.Millisecond, 250 * time.Millisecond},
+		{"retry-after seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second, 3 * time.Second},
+		{"retry-after-ms takes precedence", http.Header{"Retry-After-Ms": {"100"}, "Retry-After": {"3"}}, 100 * time.Millisecond, 100 * time.Millisecond},
+		{"retry-after date", http.Header{"Retry-After": {date}}, 28 * time.Second, 30 * time.Second},
+		{"retry-after past date", http.Header{"Retry-After": {"Mon, 02 Jan 2006 15:04:05 GMT"}}, 0, 0},
+		{"invalid header", http.Header{"Retry-After": {"soon"}}, 500 * time.Millisecond, time.Second},
+		{"negative header", http.Header{"Retry-After-Ms": {"-5"}}, 500 * time.Millisecond, time.Second},
+		{"retry-after capped", http.Header{"Retry-After": {"3600"}}, time.Minute, time.Minute},
+		{"retry-after-ms capped", http.Header{"Retry-After-Ms": {"1e30"}}, time.Minute, time.Minute},
+		{"retry-after far date capped", http.Header{"Retry-After": {time.Now().Add(24 * time.Hour).UTC().Format(http.TimeFormat)}}, time.Minute, time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := policy.Backoff(1, &http.Response{Header: tt.header})
+			if got < tt.min || got > tt.max {
+				t.Errorf("Backoff() = %v, want between %v and %v", got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestBackoffRetryAfterUncapped(t *testing.T) {
+	policy := Policy{MinBackoff: time.Second}
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"retry-after", http.Header{"Retry-After": {"3600"}}, time.Hour},
+		{"retry-after-ms overflow", http.Header{"Retry-After-Ms": {"1e30"}}, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.Backoff(1, &http.Response{Header: tt.header}); got != tt.want {
+				t.Errorf("Backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	policy := Policy{MaxRetries: 2, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name       string
+		policy     Policy
+		statuses   []int
+		wantStatus int
+		wantCalls  int32
+	}{
+		{"success", policy, []int{200}, 200, 1},
+		{"retries until success", policy, []int{429, 503, 200}, 200, 3},
+		{"gives up after max retries", policy, []int{500, 500, 500, 200}, 500, 3},
+		{"does not retry client errors", policy, []int{400, 200}, 400, 1},
+		{"no retry", NoRetry, []int{429, 200}, 429, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != "payload" {
+					t.Errorf("body = %q, want %q", body, "payload")
+				}
+				n := calls.Add(1)
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := tt.policy.Do(srv.Client(), req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoHonorsRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("retry-after-ms", "50")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	resp, err := Policy{MaxRetries: 1}.Do(srv.Client(), req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("retried after %v, want at least 50ms", elapsed)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestDoStopsWhenContextDone(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err = Policy{MaxRetries: 5, MinBackoff: time.Hour}.Do(srv.Client(), req)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Do() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Do() returned after %v, want it to stop when the context is done", elapsed)
+	}
+}
+
+func TestDoRetriesTransportErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := srv.URL
+	srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calls atomic.Int32
+	client := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	if _, err := (Policy{MaxRetries: 2}).Do(client, req); err == nil {
+		t.Fatal("Do() error = nil, want connection error")
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
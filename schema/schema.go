@@ -0,0 +1,414 @@
+// Package schema generates JSON schemas from Go types for use as tool parameters
+// and structured output definitions.
+//
+// A *Schema can be used directly as the parameters of an OpenAI function, the schema of an
+// OpenAI structured output, or the input schema of an Anthropic tool, and can validate the
+// arguments returned by a model.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON schema.
+type Schema struct {
+	Type        string `json:"-"`
+	Description string `json:"description,omitempty"`
+	// Nullable allows null in addition to Type, which is encoded as a type union.
+	Nullable bool   `json:"-"`
+	Format   string `json:"format,omitempty"`
+	// ContentEncoding is the encoding of a string holding binary data, such as "base64".
+	ContentEncoding string             `json:"contentEncoding,omitempty"`
+	Enum            []any              `json:"enum,omitempty"`
+	Minimum         *float64           `json:"minimum,omitempty"`
+	Maximum         *float64           `json:"maximum,omitempty"`
+	MinLength       *int               `json:"minLength,omitempty"`
+	MaxLength       *int               `json:"maxLength,omitempty"`
+	Properties      map[string]*Schema `json:"-"`
+	Required        []string           `json:"required,omitempty"`
+	Items           *Schema            `json:"items,omitempty"`
+	MinItems        *int               `json:"minItems,omitempty"`
+	MaxItems        *int               `json:"maxItems,omitempty"`
+	// AdditionalProperties is either a bool or a *Schema.
+	AdditionalProperties any `json:"additionalProperties,omitempty"`
+
+	// propertyOrder keeps the properties in struct field order when encoded.
+	propertyOrder []string
+	// minLength and maxLength bound the length of a string in strict mode, where OpenAI does not
+	// accept them in the schema. They are only enforced by Validate.
+	minLength, maxLength *int
+}
+
+// MarshalJSON marshals the schema to JSON, keeping the properties in field order.
+// It has a value receiver so that a Schema encodes the same whether or not it is a pointer.
+func (s Schema) MarshalJSON() ([]byte, error) {
+	type alias Schema
+	a := alias(s)
+	if s.Nullable && len(s.Enum) > 0 {
+		a.Enum = append(slices.Clone(s.Enum), nil)
+	}
+	fields, err := json.Marshal(&a)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if s.Type != "" {
+		buf.WriteString(`"type":`)
+		var schemaType any = s.Type
+		if s.Nullable {
+			schemaType = []string{s.Type, "null"}
+		}
+		typeJSON, err := json.Marshal(schemaType)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(typeJSON)
+		buf.WriteByte(',')
+	}
+	if s.Properties != nil {
+		buf.WriteString(`"properties":{`)
+		for i, name := range s.orderedProperties() {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			nameJSON, err := json.Marshal(name)
+			if err != nil {
+				return nil, err
+			}
+			propJSON, err := json.Marshal(s.Properties[name])
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(nameJSON)
+			buf.WriteByte(':')
+			buf.Write(propJSON)
+		}
+		buf.WriteString("},")
+	}
+	buf.Write(fields[1:])
+
+	out := buf.Bytes()
+	if bytes.HasSuffix(out, []byte(",}")) {
+		out = append(out[:len(out)-2], '}')
+	}
+	return out, nil
+}
+
+// orderedProperties returns the property names in field order, followed by
+// any properties that were added directly to the map.
+func (s *Schema) orderedProperties() []string {
+	names := make([]string, 0, len(s.Properties))
+	seen := make(map[string]bool, len(s.Properties))
+	for _, name := range s.propertyOrder {
+		if _, ok := s.Properties[name]; ok && !seen[name] {
+			names = append(names, name)
+			seen[name] = true
+		}
+	}
+	var rest []string
+	for name := range s.Properties {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+	sort.Strings(rest)
+	return append(names, rest...)
+}
+
+// Option configures schema generation.
+type Option func(*generator)
+
+// NonStrict generates schemas where optional fields are left out of the required properties
+// instead of being nullable. Such schemas cannot be used with OpenAI strict mode, but are
+// closer to hand-written schemas, for example for Anthropic tool input schemas.
+func NonStrict() Option {
+	return func(g *generator) {
+		g.nonStrict = true
+	}
+}
+
+// For generates the schema of T.
+func For[T any](opts ...Option) (*Schema, error) {
+	return Generate(reflect.TypeOf((*T)(nil)).Elem(), opts...)
+}
+
+// Generate generates the schema of the given type.
+//
+// Struct fields are named after their json tag and fields tagged "-" or unexported are skipped.
+// By default every property is required and additional properties are disallowed, as required
+// by OpenAI strict mode, and optional fields are nullable instead. A field is optional if it is
+// a pointer, is tagged omitempty or is tagged required:"false"; required:"true" makes it required.
+// Maps and interfaces cannot be described in strict mode and return an error unless NonStrict is used.
+// Byte slices are base64 encoded strings, as with encoding/json.
+//
+// The following struct tags are also supported:
+//
+//	description:"..."  the description of the property
+//	enum:"a,b,c"       the allowed values of the property
+//	min:"1" max:"10"   the minimum and maximum of a number, the length of a string,
+//	                   or the number of items of an array
+//
+// String lengths are not supported by OpenAI strict mode, so they are only part of the schema
+// with NonStrict, but are always enforced by Validate.
+func Generate(t reflect.Type, opts ...Option) (*Schema, error) {
+	g := &generator{seen: make(map[reflect.Type]bool)}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g.generate(t)
+}
+
+type generator struct {
+	seen      map[reflect.Type]bool
+	nonStrict bool
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (g *generator) generate(t reflect.Type) (*Schema, error) {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time", Nullable: nullable}, nil
+	}
+
+	var s *Schema
+	switch t.Kind() {
+	case reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		s = &Schema{Type: "number"}
+	case reflect.String:
+		s = &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes byte slices as base64 strings. OpenAI strict mode
+			// does not support contentEncoding, so it is only set outside of it.
+			s = &Schema{Type: "string"}
+			if g.nonStrict {
+				s.ContentEncoding = "base64"
+			}
+			break
+		}
+		items, err := g.generate(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s = &Schema{Type: "array", Items: items}
+	case reflect.Map:
+		if !g.nonStrict {
+			return nil, fmt.Errorf("schema: map type %s is not supported in strict mode", t)
+		}
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("schema: unsupported map key type %s", t.Key())
+		}
+		values, err := g.generate(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s = &Schema{Type: "object", AdditionalProperties: values}
+	case reflect.Struct:
+		var err error
+		s, err = g.generateStruct(t)
+		if err != nil {
+			return nil, err
+		}
+	case reflect.Interface:
+		if !g.nonStrict {
+			return nil, fmt.Errorf("schema: interface type %s is not supported in strict mode", t)
+		}
+		return &Schema{}, nil
+	default:
+		return nil, fmt.Errorf("schema: unsupported type %s", t)
+	}
+
+	s.Nullable = nullable
+	return s, nil
+}
+
+func (g *generator) generateStruct(t reflect.Type) (*Schema, error) {
+	if g.seen[t] {
+		return nil, fmt.Errorf("schema: recursive type %s is not supported", t)
+	}
+	g.seen[t] = true
+	defer delete(g.seen, t)
+
+	s := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+	if err := g.addFields(s, t); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// addFields adds the fields of the struct type t to s, flattening embedded structs like encoding/json.
+func (g *generator) addFields(s *Schema, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := g.addFields(s, embedded); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop, err := g.generate(field.Type)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		if err := g.applyTags(prop, field.Tag); err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+
+		optional := prop.Nullable || hasOption(opts, "omitempty")
+		switch field.Tag.Get("required") {
+		case "true":
+			optional = false
+		case "false":
+			optional = true
+		}
+
+		required := true
+		if optional {
+			if g.nonStrict {
+				required = false
+				prop.Nullable = false
+			} else if prop.Type != "" {
+				prop.Nullable = true
+			}
+		}
+
+		if _, ok := s.Properties[name]; ok {
+			s.Required = slices.DeleteFunc(s.Required, func(r string) bool { return r == name })
+		} else {
+			s.propertyOrder = append(s.propertyOrder, name)
+		}
+		if required {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+	return nil
+}
+
+// applyTags applies the description, enum, min and max struct tags to the property schema.
+func (g *generator) applyTags(s *Schema, tag reflect.StructTag) error {
+	if description := tag.Get("description"); description != "" {
+		s.Description = description
+	}
+
+	if enum := tag.Get("enum"); enum != "" {
+		for _, value := range strings.Split(enum, ",") {
+			value = strings.TrimSpace(value)
+			switch s.Type {
+			case "string":
+				s.Enum = append(s.Enum, value)
+			case "integer":
+				n, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return fmt.Errorf("schema: invalid integer enum value %q", value)
+				}
+				s.Enum = append(s.Enum, n)
+			case "number":
+				n, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return fmt.Errorf("schema: invalid number enum value %q", value)
+				}
+				s.Enum = append(s.Enum, n)
+			default:
+				return fmt.Errorf("schema: enum is not supported for type %s", s.Type)
+			}
+		}
+	}
+
+	for _, key := range []string{"min", "max"} {
+		value := tag.Get(key)
+		if value == "" {
+			continue
+		}
+		switch s.Type {
+		case "integer", "number":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("schema: invalid %s value %q", key, value)
+			}
+			if key == "min" {
+				s.Minimum = &n
+			} else {
+				s.Maximum = &n
+			}
+		case "string", "array":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("schema: invalid %s value %q", key, value)
+			}
+			switch {
+			case s.Type == "string" && key == "min" && g.nonStrict:
+				s.MinLength = &n
+			case s.Type == "string" && key == "min":
+				s.minLength = &n
+			case s.Type == "string" && g.nonStrict:
+				s.MaxLength = &n
+			case s.Type == "string":
+				s.maxLength = &n
+			case key == "min":
+				s.MinItems = &n
+			default:
+				s.MaxItems = &n
+			}
+		default:
+			return fmt.Errorf("schema: %s is not supported for type %s", key, s.Type)
+		}
+	}
+	return nil
+}
+
+func hasOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
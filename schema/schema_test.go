@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMarshalJSONValue(t *testing.T) {
+	type args struct {
+		Name  string `json:"name"`
+		Count *int   `json:"count"`
+	}
+	s, err := For[args]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"type":"object","properties":{"name":{"type":"string"},"count":{"type":["integer","null"]}},"required":["name","count"],"additionalProperties":false}`
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"pointer", s, want},
+		{"value", *s, want},
+		{"value field", struct{ Schema Schema }{*s}, `{"Schema":` + want + `}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTags(t *testing.T) {
+	type args struct {
+		Description string   `json:"description" description:"a name"`
+		StringEnum  string   `json:"string_enum" enum:"a, b"`
+		IntegerEnum int      `json:"integer_enum" enum:"1,2"`
+		NumberEnum  float64  `json:"number_enum" enum:"1.5"`
+		IntegerMin  int      `json:"integer_min" min:"1" max:"10"`
+		NumberMin   float64  `json:"number_min" min:"0.5" max:"1.5"`
+		StringMin   string   `json:"string_min" min:"1" max:"3"`
+		ArrayMin    []string `json:"array_min" min:"1" max:"2"`
+		NullableMin *string  `json:"nullable_min" min:"2"`
+	}
+
+	tests := []struct {
+		property  string
+		strict    string
+		nonStrict string
+	}{
+		{"description", `{"type":"string","description":"a name"}`, `{"type":"string","description":"a name"}`},
+		{"string_enum", `{"type":"string","enum":["a","b"]}`, `{"type":"string","enum":["a","b"]}`},
+		{"integer_enum", `{"type":"integer","enum":[1,2]}`, `{"type":"integer","enum":[1,2]}`},
+		{"number_enum", `{"type":"number","enum":[1.5]}`, `{"type":"number","enum":[1.5]}`},
+		{"integer_min", `{"type":"integer","minimum":1,"maximum":10}`, `{"type":"integer","minimum":1,"maximum":10}`},
+		{"number_min", `{"type":"number","minimum":0.5,"maximum":1.5}`, `{"type":"number","minimum":0.5,"maximum":1.5}`},
+		{"string_min", `{"type":"string"}`, `{"type":"string","minLength":1,"maxLength":3}`},
+		{"array_min", `{"type":"array","items":{"type":"string"},"minItems":1,"maxItems":2}`, `{"type":"array","items":{"type":"string"},"minItems":1,"maxItems":2}`},
+		{"nullable_min", `{"type":["string","null"]}`, `{"type":"string","minLength":2}`},
+	}
+
+	strict, err := For[args]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonStrict, err := For[args](NonStrict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.property, func(t *testing.T) {
+			got, err := json.Marshal(strict.Properties[tt.property])
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.strict {
+				t.Errorf("strict = %s, want %s", got, tt.strict)
+			}
+
+			got, err = json.Marshal(nonStrict.Properties[tt.property])
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.nonStrict {
+				t.Errorf("non-strict = %s, want %s", got, tt.nonStrict)
+			}
+		})
+	}
+}
+
+func TestValidateStringLengthStrict(t *testing.T) {
+	type args struct {
+		Name string `json:"name" min:"2" max:"3"`
+	}
+	s, err := For[args]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		data    string
+		wantErr bool
+	}{
+		{`{"name":"ab"}`, false},
+		{`{"name":"abc"}`, false},
+		{`{"name":"a"}`, true},
+		{`{"name":"abcd"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			if err := s.Validate([]byte(tt.data)); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
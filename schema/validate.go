@@ -0,0 +1,218 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationError describes a value that does not match a schema.
+type ValidationError struct {
+	// Path is the location of the value, such as $.items[0].name.
+	Path    string
+	Message string
+}
+
+// Error returns the error message.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("schema: %s: %s", e.Path, e.Message)
+}
+
+// Validate validates the JSON data, such as the arguments returned by a model for a tool call,
+// against the schema. It returns a *ValidationError for the first value that does not match.
+func (s *Schema) Validate(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return &ValidationError{Path: "$", Message: fmt.Sprintf("invalid json: %v", err)}
+	}
+	if decoder.More() {
+		return &ValidationError{Path: "$", Message: "invalid json: unexpected data after value"}
+	}
+	return s.validate("$", value)
+}
+
+func (s *Schema) validate(path string, value any) error {
+	invalid := func(format string, args ...any) error {
+		return &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)}
+	}
+
+	if value == nil {
+		if s.Nullable || s.Type == "" {
+			return nil
+		}
+		return invalid("expected %s, got null", s.Type)
+	}
+
+	switch s.Type {
+	case "":
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return invalid("expected object, got %s", typeName(value))
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				return invalid("missing required property %q", name)
+			}
+		}
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			propPath := path + "." + name
+			if prop, ok := s.Properties[name]; ok {
+				if err := prop.validate(propPath, object[name]); err != nil {
+					return err
+				}
+				continue
+			}
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					return invalid("unexpected property %q", name)
+				}
+			case *Schema:
+				if err := additional.validate(propPath, object[name]); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return invalid("expected array, got %s", typeName(value))
+		}
+		if s.MinItems != nil && len(array) < *s.MinItems {
+			return invalid("expected at least %d items, got %d", *s.MinItems, len(array))
+		}
+		if s.MaxItems != nil && len(array) > *s.MaxItems {
+			return invalid("expected at most %d items, got %d", *s.MaxItems, len(array))
+		}
+		if s.Items != nil {
+			for i, item := range array {
+				if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+					return err
+				}
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return invalid("expected string, got %s", typeName(value))
+		}
+		length := utf8.RuneCountInString(str)
+		minLength, maxLength := s.lengthBounds()
+		if minLength != nil && length < *minLength {
+			return invalid("expected at least %d characters, got %d", *minLength, length)
+		}
+		if maxLength != nil && length > *maxLength {
+			return invalid("expected at most %d characters, got %d", *maxLength, length)
+		}
+		if s.Format == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return invalid("expected date-time, got %q", str)
+			}
+		}
+		if s.ContentEncoding == "base64" {
+			if _, err := base64.StdEncoding.DecodeString(str); err != nil {
+				return invalid("expected base64 encoded string")
+			}
+		}
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			return invalid("expected %s, got %s", s.Type, typeName(value))
+		}
+		n, err := number.Float64()
+		if err != nil {
+			return invalid("invalid number %s", number)
+		}
+		if s.Type == "integer" && n != math.Trunc(n) {
+			return invalid("expected integer, got %s", number)
+		}
+		if s.Minimum != nil && n < *s.Minimum {
+			return invalid("expected at least %v, got %s", *s.Minimum, number)
+		}
+		if s.Maximum != nil && n > *s.Maximum {
+			return invalid("expected at most %v, got %s", *s.Maximum, number)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return invalid("expected boolean, got %s", typeName(value))
+		}
+	default:
+		return invalid("unsupported schema type %s", s.Type)
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		return invalid("value is not one of %v", s.Enum)
+	}
+	return nil
+}
+
+func inEnum(enum []any, value any) bool {
+	for _, allowed := range enum {
+		switch v := value.(type) {
+		case string:
+			if allowed == v {
+				return true
+			}
+		case json.Number:
+			n, err := v.Float64()
+			if err != nil {
+				return false
+			}
+			switch a := allowed.(type) {
+			case int64:
+				if float64(a) == n {
+					return true
+				}
+			case float64:
+				if a == n {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func typeName(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// lengthBounds returns the length bounds of a string, including those that are not encoded in strict mode.
+func (s *Schema) lengthBounds() (minLength, maxLength *int) {
+	minLength, maxLength = s.MinLength, s.MaxLength
+	if minLength == nil {
+		minLength = s.minLength
+	}
+	if maxLength == nil {
+		maxLength = s.maxLength
+	}
+	return minLength, maxLength
+}
@@ -0,0 +1,300 @@
+// Package tools provides a registry of typed Go functions that are exposed as tools to the
+// OpenAI and Anthropic chat clients, and loops that dispatch the tool calls of a model until
+// it stops requesting them.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/joeychilson/ai/anthropic"
+	"github.com/joeychilson/ai/openai"
+	"github.com/joeychilson/ai/schema"
+)
+
+// ErrMaxIterations is returned when the model still requests tools after the maximum number of iterations.
+var ErrMaxIterations = errors.New("tools: maximum iterations reached")
+
+// Tool is a function registered in a Registry.
+type Tool struct {
+	Name        string
+	Description string
+	Schema      *schema.Schema
+	call        func(ctx context.Context, args json.RawMessage) (string, error)
+}
+
+// Registry is a set of tools that can be called by a model.
+type Registry struct {
+	tools map[string]*Tool
+	order []string
+}
+
+// NewRegistry creates a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		tools: make(map[string]*Tool),
+	}
+}
+
+// Register adds a tool with the given name and description to the registry.
+//
+// The parameters schema of the tool is generated from Args, which must be a struct type, and the
+// arguments of a call are validated against it before being decoded into Args. A string result
+// is passed to the model as is, any other result is encoded as JSON.
+func Register[Args, Result any](r *Registry, name, description string, fn func(ctx context.Context, args Args) (Result, error)) error {
+	if _, ok := r.tools[name]; ok {
+		return fmt.Errorf("tools: tool %q is already registered", name)
+	}
+
+	s, err := schema.For[Args]()
+	if err != nil {
+		return fmt.Errorf("tools: tool %q: %w", name, err)
+	}
+	if s.Type != "object" {
+		return fmt.Errorf("tools: tool %q: arguments type must be a struct, got %s", name, reflect.TypeOf((*Args)(nil)).Elem())
+	}
+
+	r.tools[name] = &Tool{
+		Name:        name,
+		Description: description,
+		Schema:      s,
+		call: func(ctx context.Context, raw json.RawMessage) (string, error) {
+			if len(raw) == 0 {
+				raw = json.RawMessage("{}")
+			}
+			if err := s.Validate(raw); err != nil {
+				return "", err
+			}
+
+			var args Args
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+
+			result, err := fn(ctx, args)
+			if err != nil {
+				return "", err
+			}
+			if str, ok := any(result).(string); ok {
+				return str, nil
+			}
+
+			data, err := json.Marshal(result)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %w", err)
+			}
+			return string(data), nil
+		},
+	}
+	r.order = append(r.order, name)
+	return nil
+}
+
+// Tools returns the registered tools in registration order.
+func (r *Registry) Tools() []*Tool {
+	tools := make([]*Tool, 0, len(r.order))
+	for _, name := range r.order {
+		tools = append(tools, r.tools[name])
+	}
+	return tools
+}
+
+// Call calls the named tool with the given JSON arguments and returns its result.
+func (r *Registry) Call(ctx context.Context, name string, args json.RawMessage) (result string, err error) {
+	tool, ok := r.tools[name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", name)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("tool %q panicked: %v", name, p)
+		}
+	}()
+	return tool.call(ctx, args)
+}
+
+// OpenAITools returns the registered tools as OpenAI function tools with strict schemas.
+func (r *Registry) OpenAITools() []openai.Tool {
+	tools := make([]openai.Tool, 0, len(r.order))
+	for _, tool := range r.Tools() {
+		t := openai.NewFunctionTool(tool.Name, tool.Description, tool.Schema)
+		t.Function.Strict = true
+		tools = append(tools, t)
+	}
+	return tools
+}
+
+// AnthropicTools returns the registered tools as Anthropic tools.
+func (r *Registry) AnthropicTools() []anthropic.Tool {
+	tools := make([]anthropic.Tool, 0, len(r.order))
+	for _, tool := range r.Tools() {
+		tools = append(tools, anthropic.Tool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: tool.Schema,
+		})
+	}
+	return tools
+}
+
+// RunOptions configures a tool loop.
+type RunOptions struct {
+	// MaxIterations is the maximum number of chat requests. It defaults to 10.
+	MaxIterations int
+	// MaxConcurrency limits the number of tools called in parallel. It defaults to no limit.
+	MaxConcurrency int
+}
+
+func (o *RunOptions) maxIterations() int {
+	if o == nil || o.MaxIterations <= 0 {
+		return 10
+	}
+	return o.MaxIterations
+}
+
+func (o *RunOptions) maxConcurrency() int {
+	if o == nil {
+		return 0
+	}
+	return o.MaxConcurrency
+}
+
+// toolCall is a tool call requested by a model.
+type toolCall struct {
+	name string
+	args json.RawMessage
+}
+
+// toolResult is the result of a tool call.
+type toolResult struct {
+	content string
+	err     error
+}
+
+// callAll calls the tools in parallel and returns their results in the same order.
+func (r *Registry) callAll(ctx context.Context, calls []toolCall, maxConcurrency int) []toolResult {
+	results := make([]toolResult, len(calls))
+
+	var sem chan struct{}
+	if maxConcurrency > 0 {
+		sem = make(chan struct{}, maxConcurrency)
+	}
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func(i int, call toolCall) {
+			defer wg.Done()
+			if sem != nil {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+			}
+			content, err := r.Call(ctx, call.name, call.args)
+			results[i] = toolResult{content: content, err: err}
+		}(i, call)
+	}
+	wg.Wait()
+	return results
+}
+
+// RunOpenAI performs chat completion requests, calling the tools requested by the model and
+// sending their results back, until the model stops requesting tools. Tool errors are sent
+// back to the model as the tool result. The messages of the request are extended with the
+// conversation, and the tools of the request default to the registered tools.
+//
+// If the model still requests tools after the maximum number of iterations, the last response
+// is returned with ErrMaxIterations.
+func RunOpenAI(ctx context.Context, client *openai.Client, req *openai.ChatRequest, r *Registry, opts *RunOptions) (*openai.ChatResponse, error) {
+	if len(req.Tools) == 0 {
+		req.Tools = r.OpenAITools()
+	}
+
+	var resp *openai.ChatResponse
+	for i := 0; i < opts.maxIterations(); i++ {
+		var err error
+		resp, err = client.Chat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Choices) == 0 || len(resp.Choices[0].Message.ToolCalls) == 0 {
+			return resp, nil
+		}
+
+		message := resp.Choices[0].Message
+		req.Messages = append(req.Messages, openai.AssistantMessage{
+			Content:   message.Content,
+			ToolCalls: message.ToolCalls,
+		})
+
+		calls := make([]toolCall, len(message.ToolCalls))
+		for i, call := range message.ToolCalls {
+			calls[i] = toolCall{name: call.Function.Name, args: json.RawMessage(call.Function.Arguments)}
+		}
+
+		for i, result := range r.callAll(ctx, calls, opts.maxConcurrency()) {
+			content := result.content
+			if result.err != nil {
+				content = "error: " + result.err.Error()
+			}
+			req.Messages = append(req.Messages, openai.ToolMessage{
+				Content:    content,
+				ToolCallID: message.ToolCalls[i].ID,
+			})
+		}
+	}
+	return resp, ErrMaxIterations
+}
+
+// RunAnthropic sends chat requests, calling the tools requested by the model and sending their
+// results back, until the model stops requesting tools. Tool errors are sent back to the model
+// as error tool results. The messages of the request are extended with the conversation, and
+// the tools of the request default to the registered tools.
+//
+// If the model still requests tools after the maximum number of iterations, the last message
+// is returned with ErrMaxIterations.
+func RunAnthropic(ctx context.Context, client *anthropic.Client, req *anthropic.ChatRequest, r *Registry, opts *RunOptions) (*anthropic.ChatMessage, error) {
+	if len(req.Tools) == 0 {
+		req.Tools = r.AnthropicTools()
+	}
+
+	var message *anthropic.ChatMessage
+	for i := 0; i < opts.maxIterations(); i++ {
+		var err error
+		message, err = client.Chat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		toolUses := message.ToolUses()
+		if len(toolUses) == 0 {
+			return message, nil
+		}
+
+		req.Messages = append(req.Messages, message.AssistantMessage())
+
+		calls := make([]toolCall, len(toolUses))
+		for i, toolUse := range toolUses {
+			calls[i] = toolCall{name: toolUse.Name, args: toolUse.Input}
+		}
+
+		results := r.callAll(ctx, calls, opts.maxConcurrency())
+		content := make([]anthropic.Content, len(results))
+		for i, result := range results {
+			toolResult := anthropic.ToolResultContent{ToolUseID: toolUses[i].ID}
+			if result.err != nil {
+				toolResult.Content = []anthropic.Content{anthropic.TextContent{Text: result.err.Error()}}
+				toolResult.IsError = true
+			} else if result.content != "" {
+				toolResult.Content = []anthropic.Content{anthropic.TextContent{Text: result.content}}
+			}
+			content[i] = toolResult
+		}
+		req.Messages = append(req.Messages, anthropic.UserMessage{Content: content})
+	}
+	return message, ErrMaxIterations
+}
@@ -0,0 +1,125 @@
+package voyageai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ContextualizedModel is a model that can be used for contextualized chunk embeddings.
+type ContextualizedModel string
+
+const (
+	ModelVoyageContext3 ContextualizedModel = "voyage-context-3"
+)
+
+// ContextualizedEmbedRequest is a request to embed the chunks of documents, where each chunk
+// embedding captures the context of the whole document.
+type ContextualizedEmbedRequest struct {
+	Model ContextualizedModel `json:"model"`
+	// Inputs holds the documents, each as the list of its chunks in order.
+	Inputs          [][]string     `json:"inputs"`
+	InputType       InputType      `json:"input_type,omitempty"`
+	OutputDimension int            `json:"output_dimension,omitempty"`
+	OutputDType     OutputDType    `json:"output_dtype,omitempty"`
+	EncodingFormat  EncodingFormat `json:"encoding_format,omitempty"`
+}
+
+// ContextualizedEmbeddingData is the data for the chunk embeddings of a document.
+type ContextualizedEmbeddingData struct {
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Index  int             `json:"index"`
+}
+
+// ContextualizedEmbedResponse is a response to a contextualized embedding request.
+type ContextualizedEmbedResponse struct {
+	Object string                        `json:"object"`
+	Data   []ContextualizedEmbeddingData `json:"data"`
+	Model  string                        `json:"model"`
+	Usage  Usage                         `json:"usage"`
+}
+
+// ChunkEmbedding is the embedding of a chunk at the given position of the request inputs.
+type ChunkEmbedding struct {
+	Document int
+	Chunk    int
+	EmbeddingData
+}
+
+// Chunks returns the embeddings of all chunks, in the order of the request inputs.
+func (r *ContextualizedEmbedResponse) Chunks() []ChunkEmbedding {
+	var chunks []ChunkEmbedding
+	for _, document := range r.Data {
+		for _, data := range document.Data {
+			chunks = append(chunks, ChunkEmbedding{
+				Document:      document.Index,
+				Chunk:         data.Index,
+				EmbeddingData: data,
+			})
+		}
+	}
+	return chunks
+}
+
+// ContextualizedEmbed sends a request to create contextualized embeddings for the chunks of the given documents.
+// The response is ordered so that Data[i].Data[j] is the embedding of req.Inputs[i][j].
+// Embeddings are requested in base64 unless an encoding format is set.
+func (c *Client) ContextualizedEmbed(ctx context.Context, req *ContextualizedEmbedRequest) (*ContextualizedEmbedResponse, error) {
+	embedReq := *req
+	switch embedReq.EncodingFormat {
+	case "":
+		embedReq.EncodingFormat = EncodingFormatBase64
+	case EncodingFormatFloat:
+		embedReq.EncodingFormat = ""
+	}
+
+	resp, err := c.request(ctx, "/contextualizedembeddings", &embedReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.decodeError(resp)
+	}
+
+	var embeddingResp ContextualizedEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
+		return nil, err
+	}
+
+	if len(embeddingResp.Data) != len(req.Inputs) {
+		return nil, fmt.Errorf("expected embeddings for %d documents, got %d", len(req.Inputs), len(embeddingResp.Data))
+	}
+	documents := make([]ContextualizedEmbeddingData, len(req.Inputs))
+	for _, document := range embeddingResp.Data {
+		i := document.Index
+		if i < 0 || i >= len(documents) || documents[i].Data != nil {
+			return nil, fmt.Errorf("invalid document index %d", i)
+		}
+		if len(document.Data) != len(req.Inputs[i]) {
+			return nil, fmt.Errorf("expected %d chunk embeddings for document %d, got %d", len(req.Inputs[i]), i, len(document.Data))
+		}
+
+		chunks := make([]EmbeddingData, len(document.Data))
+		seen := make([]bool, len(document.Data))
+		for _, data := range document.Data {
+			j := data.Index
+			if j < 0 || j >= len(chunks) || seen[j] {
+				return nil, fmt.Errorf("invalid chunk index %d for document %d", j, i)
+			}
+			if err := data.decode(req.OutputDType); err != nil {
+				return nil, err
+			}
+			chunks[j] = data
+			seen[j] = true
+		}
+		document.Data = chunks
+		documents[i] = document
+	}
+	embeddingResp.Data = documents
+
+	return &embeddingResp, nil
+}
@@ -0,0 +1,65 @@
+package voyageai
+
+import (
+	"context"
+
+	"github.com/joeychilson/ai/internal/batch"
+)
+
+var defaultBatchLimits = batch.Limits{
+	MaxItems:    128,
+	MaxTokens:   120000,
+	Concurrency: 4,
+}
+
+// BatchOptions configures how EmbedAll splits and sends the input.
+type BatchOptions struct {
+	// MaxInputs is the maximum number of inputs per request. Defaults to 128.
+	MaxInputs int
+	// MaxTokens is the maximum number of estimated tokens per request. Defaults to 120000, the limit of voyage-large-2.
+	MaxTokens int
+	// Concurrency is the maximum number of requests sent at the same time. Defaults to 4.
+	Concurrency int
+}
+
+// EmbedAll embeds any number of inputs in batches that stay under the request limits of the API,
+// sending several batches at once. The embeddings keep the order of req.Input, with Index set to
+// their position in it, and the usage is the total of all batches. Requests are retried by the
+// retry policy of the client.
+func (c *Client) EmbedAll(ctx context.Context, req *EmbedRequest, opts *BatchOptions) (*EmbedResponse, error) {
+	if opts == nil {
+		opts = &BatchOptions{}
+	}
+	limits := batch.Limits{
+		MaxItems:    opts.MaxInputs,
+		MaxTokens:   opts.MaxTokens,
+		Concurrency: opts.Concurrency,
+	}
+
+	data, responses, err := batch.Embed(ctx, req.Input, limits, defaultBatchLimits,
+		func(d *EmbeddingData) *int { return &d.Index },
+		func(ctx context.Context, input []string) (*EmbedResponse, []EmbeddingData, error) {
+			batchReq := *req
+			batchReq.Input = input
+
+			resp, err := c.Embed(ctx, &batchReq)
+			if err != nil {
+				return nil, nil, err
+			}
+			return resp, resp.Data, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	embedResp := &EmbedResponse{
+		Object: "list",
+		Data:   data,
+		Model:  string(req.Model),
+	}
+	for _, resp := range responses {
+		embedResp.Model = resp.Model
+		embedResp.Usage.TotalTokens += resp.Usage.TotalTokens
+	}
+	return embedResp, nil
+}
@@ -0,0 +1,191 @@
+package voyageai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// MultimodalModel is a model that can be used for multimodal embeddings.
+type MultimodalModel string
+
+const (
+	ModelVoyageMultimodal3 MultimodalModel = "voyage-multimodal-3"
+)
+
+// MultimodalContent is a piece of content of a multimodal input.
+type MultimodalContent interface {
+	Type() string
+}
+
+// TextContent is a text content of a multimodal input.
+type TextContent struct {
+	Text string `json:"text"`
+}
+
+// Type returns the type of the text content.
+func (c TextContent) Type() string {
+	return "text"
+}
+
+// MarshalJSON marshals the text content to JSON.
+func (c TextContent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}{
+		Type: c.Type(),
+		Text: c.Text,
+	})
+}
+
+// ImageURLContent is an image content of a multimodal input, referenced by URL.
+type ImageURLContent struct {
+	URL string `json:"image_url"`
+}
+
+// Type returns the type of the image URL content.
+func (c ImageURLContent) Type() string {
+	return "image_url"
+}
+
+// MarshalJSON marshals the image URL content to JSON.
+func (c ImageURLContent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		URL  string `json:"image_url"`
+	}{
+		Type: c.Type(),
+		URL:  c.URL,
+	})
+}
+
+// ImageBase64Content is an image content of a multimodal input, embedded as a base64 data URL
+// such as "data:image/png;base64,...".
+type ImageBase64Content struct {
+	Data string `json:"image_base64"`
+}
+
+// Type returns the type of the image base64 content.
+func (c ImageBase64Content) Type() string {
+	return "image_base64"
+}
+
+// MarshalJSON marshals the image base64 content to JSON.
+func (c ImageBase64Content) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		Data string `json:"image_base64"`
+	}{
+		Type: c.Type(),
+		Data: c.Data,
+	})
+}
+
+// NewImageContent creates an image base64 content from the encoded image data.
+// The media type is detected from the data.
+func NewImageContent(data []byte) (ImageBase64Content, error) {
+	mediaType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if !strings.HasPrefix(mediaType, "image/") {
+		return ImageBase64Content{}, fmt.Errorf("unsupported image media type %s", mediaType)
+	}
+	return ImageBase64Content{
+		Data: "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data),
+	}, nil
+}
+
+// NewImageContentFromFile creates an image base64 content from the image file at the given path.
+func NewImageContentFromFile(path string) (ImageBase64Content, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ImageBase64Content{}, err
+	}
+	return NewImageContent(data)
+}
+
+// NewImageContentFromImage creates an image base64 content from the image, encoded as PNG.
+func NewImageContentFromImage(img image.Image) (ImageBase64Content, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ImageBase64Content{}, err
+	}
+	return ImageBase64Content{
+		Data: "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+// MultimodalInput is an input made of interleaved text and images.
+type MultimodalInput struct {
+	Content []MultimodalContent `json:"content"`
+}
+
+// NewMultimodalInput creates a multimodal input from the given content.
+func NewMultimodalInput(content ...MultimodalContent) MultimodalInput {
+	return MultimodalInput{Content: content}
+}
+
+// MultimodalEmbedRequest is a request to embed multimodal inputs.
+type MultimodalEmbedRequest struct {
+	Model          MultimodalModel   `json:"model"`
+	Inputs         []MultimodalInput `json:"inputs"`
+	InputType      InputType         `json:"input_type,omitempty"`
+	Truncation     *bool             `json:"truncation,omitempty"`
+	OutputEncoding EncodingFormat    `json:"output_encoding,omitempty"`
+}
+
+// MultimodalUsage is the usage of a multimodal embedding.
+type MultimodalUsage struct {
+	TextTokens  int `json:"text_tokens"`
+	ImagePixels int `json:"image_pixels"`
+	TotalTokens int `json:"total_tokens"`
+}
+
+// MultimodalEmbedResponse is a response to a multimodal embedding request.
+type MultimodalEmbedResponse struct {
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  MultimodalUsage `json:"usage"`
+}
+
+// MultimodalEmbed sends a request to create embeddings for the given multimodal inputs.
+// The text and image embeddings share the same vector space.
+// Embeddings are requested in base64 unless an output encoding is set.
+func (c *Client) MultimodalEmbed(ctx context.Context, req *MultimodalEmbedRequest) (*MultimodalEmbedResponse, error) {
+	embedReq := *req
+	switch embedReq.OutputEncoding {
+	case "":
+		embedReq.OutputEncoding = EncodingFormatBase64
+	case EncodingFormatFloat:
+		embedReq.OutputEncoding = ""
+	}
+
+	resp, err := c.request(ctx, "/multimodalembeddings", &embedReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.decodeError(resp)
+	}
+
+	var embeddingResp MultimodalEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
+		return nil, err
+	}
+	for i := range embeddingResp.Data {
+		if err := embeddingResp.Data[i].decode(OutputDTypeFloat); err != nil {
+			return nil, err
+		}
+	}
+
+	return &embeddingResp, nil
+}
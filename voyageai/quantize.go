@@ -0,0 +1,86 @@
+package voyageai
+
+import "math/bits"
+
+// Int8 returns the embedding as int8 values. It is meant for int8 and binary embeddings.
+func (d EmbeddingData) Int8() []int8 {
+	values := make([]int8, len(d.Embedding))
+	for i, v := range d.Embedding {
+		values[i] = int8(v)
+	}
+	return values
+}
+
+// Uint8 returns the embedding as uint8 values. It is meant for uint8 and ubinary embeddings.
+func (d EmbeddingData) Uint8() []uint8 {
+	values := make([]uint8, len(d.Embedding))
+	for i, v := range d.Embedding {
+		values[i] = uint8(v)
+	}
+	return values
+}
+
+// Bits returns the packed bits of a binary or ubinary embedding, eight dimensions per byte with the
+// first dimension in the most significant bit. The offset of binary embeddings is removed, so both
+// data types return the same bits.
+func (d EmbeddingData) Bits() []byte {
+	packed := make([]byte, len(d.Embedding))
+	for i, v := range d.Embedding {
+		if d.dtype == OutputDTypeBinary {
+			packed[i] = byte(int(v) + 128)
+		} else {
+			packed[i] = byte(v)
+		}
+	}
+	return packed
+}
+
+// Hamming returns the number of bits that differ between the packed bit embeddings a and b,
+// which must have the same length.
+func Hamming(a, b []byte) int {
+	if len(a) != len(b) {
+		panic("voyageai: embeddings have different lengths")
+	}
+	distance := 0
+	for i := range a {
+		distance += bits.OnesCount8(a[i] ^ b[i])
+	}
+	return distance
+}
+
+// DotInt8 returns the dot product of the int8 embeddings a and b, which must have the same length.
+func DotInt8(a, b []int8) int32 {
+	if len(a) != len(b) {
+		panic("voyageai: embeddings have different lengths")
+	}
+	var dot int32
+	for i := range a {
+		dot += int32(a[i]) * int32(b[i])
+	}
+	return dot
+}
+
+// DotUint8 returns the dot product of the uint8 embeddings a and b, which must have the same length.
+func DotUint8(a, b []uint8) uint32 {
+	if len(a) != len(b) {
+		panic("voyageai: embeddings have different lengths")
+	}
+	var dot uint32
+	for i := range a {
+		dot += uint32(a[i]) * uint32(b[i])
+	}
+	return dot
+}
+
+// Dot returns the dot product of the float embeddings a and b, which must have the same length.
+// Voyage embeddings are normalized, so it is equal to their cosine similarity.
+func Dot(a, b []float32) float32 {
+	if len(a) != len(b) {
+		panic("voyageai: embeddings have different lengths")
+	}
+	var dot float32
+	for i := range a {
+		dot += a[i] * b[i]
+	}
+	return dot
+}
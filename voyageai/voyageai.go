@@ -3,9 +3,14 @@ package voyageai
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
+
+	"github.com/joeychilson/ai/internal/embed"
+	"github.com/joeychilson/ai/retry"
 )
 
 const (
@@ -14,18 +19,33 @@ const (
 
 // Client is a client for the VoyageAI API.
 type Client struct {
-	baseURL    string
-	token      string
-	httpClient *http.Client
+	baseURL     string
+	token       string
+	httpClient  *http.Client
+	headers     http.Header
+	userAgent   string
+	timeout     time.Duration
+	retryPolicy retry.Policy
 }
 
-// New creates a new Client using the given token.
-func New(token string) *Client {
-	return &Client{
-		baseURL:    defaultBaseURL,
-		token:      token,
-		httpClient: http.DefaultClient,
+// New creates a new Client using the given token and options.
+func New(token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:     defaultBaseURL,
+		token:       token,
+		httpClient:  http.DefaultClient,
+		headers:     make(http.Header),
+		retryPolicy: retry.DefaultPolicy(),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	if c.timeout > 0 {
+		httpClient := *c.httpClient
+		httpClient.Timeout = c.timeout
+		c.httpClient = &httpClient
+	}
+	return c
 }
 
 // Usage is the usage of an embedding.
@@ -56,22 +76,114 @@ type EncodingFormat string
 
 const (
 	EncodingFormatBase64 EncodingFormat = "base64"
+	// EncodingFormatFloat requests the embeddings as arrays of numbers.
+	EncodingFormatFloat EncodingFormat = "float"
+)
+
+// OutputDType is the data type of the returned embeddings.
+type OutputDType string
+
+const (
+	OutputDTypeFloat OutputDType = "float"
+	// OutputDTypeInt8 returns the embeddings quantized to values between -128 and 127.
+	OutputDTypeInt8 OutputDType = "int8"
+	// OutputDTypeUint8 returns the embeddings quantized to values between 0 and 255.
+	OutputDTypeUint8 OutputDType = "uint8"
+	// OutputDTypeBinary returns one bit per dimension, packed into int8 values offset by 128.
+	OutputDTypeBinary OutputDType = "binary"
+	// OutputDTypeUbinary returns one bit per dimension, packed into uint8 values.
+	OutputDTypeUbinary OutputDType = "ubinary"
 )
 
 // EmbedRequest is a request to embed text.
 type EmbedRequest struct {
-	Model          EmbeddingModel `json:"model"`
-	Input          []string       `json:"input"`
-	InputType      InputType      `json:"input_type,omitempty"`
-	Truncation     *bool          `json:"truncation,omitempty"`
-	EncodingFormat EncodingFormat `json:"encoding_format,omitempty"`
+	Model           EmbeddingModel `json:"model"`
+	Input           []string       `json:"input"`
+	InputType       InputType      `json:"input_type,omitempty"`
+	Truncation      *bool          `json:"truncation,omitempty"`
+	EncodingFormat  EncodingFormat `json:"encoding_format,omitempty"`
+	OutputDimension int            `json:"output_dimension,omitempty"`
+	OutputDType     OutputDType    `json:"output_dtype,omitempty"`
 }
 
 // EmbeddingData is the data for an embedding.
+// Quantized embeddings hold their integer values in Embedding, use Int8, Uint8 or Bits to get them in their
+// compact form.
 type EmbeddingData struct {
 	Object    string    `json:"object"`
 	Embedding []float32 `json:"embedding"`
 	Index     int       `json:"index"`
+
+	dtype OutputDType
+	raw   []byte
+}
+
+// UnmarshalJSON unmarshals the embedding data from JSON, decoding base64 encoded vectors.
+func (d *EmbeddingData) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Object    string          `json:"object"`
+		Embedding json.RawMessage `json:"embedding"`
+		Index     int             `json:"index"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	d.Object = raw.Object
+	d.Index = raw.Index
+	d.Embedding = nil
+	d.raw = nil
+
+	var s string
+	if err := json.Unmarshal(raw.Embedding, &s); err != nil {
+		vector, err := embed.Decode(raw.Embedding)
+		if err != nil {
+			return err
+		}
+		d.Embedding = vector
+		return nil
+	}
+
+	// The data type of base64 encoded embeddings is only known from the request,
+	// so the bytes are kept until Embed decodes them.
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("invalid base64 embedding: %w", err)
+	}
+	d.raw = b
+	if vector, err := embed.DecodeFloat32(b); err == nil {
+		d.Embedding = vector
+	}
+	return nil
+}
+
+// decode decodes the base64 encoded bytes of the embedding as the given data type.
+func (d *EmbeddingData) decode(dtype OutputDType) error {
+	d.dtype = dtype
+	raw := d.raw
+	d.raw = nil
+	if raw == nil {
+		return nil
+	}
+
+	switch dtype {
+	case "", OutputDTypeFloat:
+		vector, err := embed.DecodeFloat32(raw)
+		if err != nil {
+			return err
+		}
+		d.Embedding = vector
+	case OutputDTypeInt8, OutputDTypeBinary:
+		d.Embedding = make([]float32, len(raw))
+		for i, b := range raw {
+			d.Embedding[i] = float32(int8(b))
+		}
+	default:
+		d.Embedding = make([]float32, len(raw))
+		for i, b := range raw {
+			d.Embedding[i] = float32(b)
+		}
+	}
+	return nil
 }
 
 // EmbedResponse is a response to an embedding request.
@@ -83,8 +195,19 @@ type EmbedResponse struct {
 }
 
 // Embed sends a request to create embeddings for the given text.
+// Embeddings are requested in base64 unless an encoding format is set, since it is much smaller
+// on the wire, and are always decoded to float32 values.
 func (c *Client) Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse, error) {
-	resp, err := c.request(ctx, "/embeddings", req)
+	embedReq := *req
+	switch embedReq.EncodingFormat {
+	case "":
+		embedReq.EncodingFormat = EncodingFormatBase64
+	case EncodingFormatFloat:
+		// The API returns arrays of numbers when no encoding format is set.
+		embedReq.EncodingFormat = ""
+	}
+
+	resp, err := c.request(ctx, "/embeddings", &embedReq)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +221,11 @@ func (c *Client) Embed(ctx context.Context, req *EmbedRequest) (*EmbedResponse,
 	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
 		return nil, err
 	}
+	for i := range embeddingResp.Data {
+		if err := embeddingResp.Data[i].decode(req.OutputDType); err != nil {
+			return nil, err
+		}
+	}
 
 	return &embeddingResp, nil
 }
@@ -169,19 +297,17 @@ func (c *Client) request(ctx context.Context, path string, body any) (*http.Resp
 
 	httpReq.Header.Set("Authorization", "Bearer "+c.token)
 	httpReq.Header.Set("Content-Type", "application/json")
+	if c.userAgent != "" {
+		httpReq.Header.Set("User-Agent", c.userAgent)
+	}
+	for key, values := range c.headers {
+		httpReq.Header[key] = values
+	}
 
-	return c.httpClient.Do(httpReq)
+	return c.retryPolicy.Do(c.httpClient, httpReq)
 }
 
 // ErrorResponse is an error response from the API.
 type ErrorResponse struct {
 	Detail string `json:"detail"`
 }
-
-func (c *Client) decodeError(resp *http.Response) error {
-	var errResp ErrorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-		return fmt.Errorf("error decoding error response: %w", err)
-	}
-	return fmt.Errorf("%s: %s", resp.Status, errResp.Detail)
-}